@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/wbw1537/synapse"
 	"github.com/wbw1537/synapse/internal/api"
+	"github.com/wbw1537/synapse/internal/axon"
 	"github.com/wbw1537/synapse/internal/broker"
 	"github.com/wbw1537/synapse/internal/config"
 	"github.com/wbw1537/synapse/internal/db"
@@ -16,6 +19,11 @@ import (
 )
 
 func main() {
+	// 0. CLI subcommands (no server needed)
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	// 1. Load Config
 	cfg := config.Load()
 	log.Println("Synapse starting...")
@@ -33,8 +41,10 @@ func main() {
 
 	// 3. Initialize Service Manager
 	svcManager := service.NewManager(database, cfg)
-	// Start TTL Monitor (Run every 10 seconds)
-	svcManager.StartTTLMonitor(10 * time.Second)
+	// Prune component history past its retention
+	svcManager.StartHistoryRetention(10 * time.Minute)
+	// Archive services that stay offline (if SYNAPSE_ARCHIVE_AFTER is set)
+	svcManager.StartArchiveSweeper(time.Hour)
 
 	// 4. Start HTTP API
 	apiServer := api.NewServer(cfg, svcManager, synapse.UI)
@@ -75,12 +85,28 @@ func main() {
 		return token.Error()
 	})
 
+	// 6.6 Arm heartbeat deadlines (needs the publisher for status updates)
+	if err := svcManager.StartDeadlines(); err != nil {
+		log.Fatalf("Failed to schedule heartbeat deadlines: %v", err)
+	}
+	// Probes write into services like axons do, so start them after the deadlines
+	if err := svcManager.StartProbes(); err != nil {
+		log.Fatalf("Failed to schedule probes: %v", err)
+	}
+	if err := svcManager.StartScrapes(); err != nil {
+		log.Fatalf("Failed to schedule scrape targets: %v", err)
+	}
+	if err := svcManager.StartJobs(); err != nil {
+		log.Fatalf("Failed to schedule job checks: %v", err)
+	}
+
 	// 7. Subscribe to Discovery Topic
 	topic := "synapse/v1/discovery/#"
 	if token := client.Subscribe(topic, 0, func(client mqtt.Client, msg mqtt.Message) {
 		// Log receipt (optional, verbose)
 		// log.Printf("Received message on %s", msg.Topic())
-		if err := svcManager.Upsert(msg.Payload()); err != nil {
+		id := strings.TrimPrefix(msg.Topic(), "synapse/v1/discovery/")
+		if err := svcManager.Upsert(id, msg.Payload()); err != nil {
 			log.Printf("Error processing discovery payload: %v", err)
 		}
 	}); token.Wait() && token.Error() != nil {
@@ -88,9 +114,64 @@ func main() {
 	}
 
 	log.Printf("Listening for services on %s", topic)
+
+	// 7.5 Subscribe to State Topic (partial updates)
+	stateTopic := "synapse/v1/state/#"
+	if token := client.Subscribe(stateTopic, 0, func(client mqtt.Client, msg mqtt.Message) {
+		id := strings.TrimPrefix(msg.Topic(), "synapse/v1/state/")
+		if err := svcManager.UpdateState(id, msg.Payload()); err != nil {
+			log.Printf("Error processing state payload for %s: %v", id, err)
+		}
+	}); token.Wait() && token.Error() != nil {
+		log.Fatalf("Failed to subscribe to topic %s: %v", stateTopic, token.Error())
+	}
+
+	// 7.6 Subscribe to Run Topic (job runs)
+	runTopic := "synapse/v1/run/#"
+	if token := client.Subscribe(runTopic, 0, func(client mqtt.Client, msg mqtt.Message) {
+		id := strings.TrimPrefix(msg.Topic(), "synapse/v1/run/")
+		if _, err := svcManager.ReportRun(id, msg.Payload()); err != nil {
+			log.Printf("Error processing run report for %s: %v", id, err)
+		}
+	}); token.Wait() && token.Error() != nil {
+		log.Fatalf("Failed to subscribe to topic %s: %v", runTopic, token.Error())
+	}
+
 	log.Println("Synapse is running. Press Ctrl+C to stop.")
 
 	// 8. Wait for shutdown signal
 	broker.WaitForSignal()
 	log.Println("Shutting down...")
 }
+
+// runValidate checks axon.toml files and returns the process exit code:
+// 0 if all files are valid, 1 if any has errors, 2 on usage errors.
+func runValidate(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: synapse validate <axon.toml> [more.toml...]")
+		return 2
+	}
+
+	code := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			code = 1
+			continue
+		}
+
+		_, report := axon.Load(data)
+		for _, line := range strings.Split(strings.TrimSpace(axon.FormatIssues(report)), "\n") {
+			if line != "" {
+				fmt.Printf("%s: %s\n", path, line)
+			}
+		}
+		if report.HasErrors() {
+			code = 1
+		} else {
+			fmt.Printf("%s: OK\n", path)
+		}
+	}
+	return code
+}
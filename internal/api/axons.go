@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/wbw1537/synapse/internal/axon"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+)
+
+// axonResult is the response of the axon.toml endpoints
+type axonResult struct {
+	Valid   bool               `json:"valid"`
+	Issues  []validation.Issue `json:"issues"`
+	Service *models.Service    `json:"service,omitempty"`
+}
+
+func (s *Server) validateAxon(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	_, report := axon.Load(body)
+	writeAxonResult(w, report, nil)
+}
+
+func (s *Server) importAxon(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	svc, report, err := s.svcManager.ImportAxon(token, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeAxonResult(w, report, svc)
+}
+
+func writeAxonResult(w http.ResponseWriter, report *validation.Report, svc *models.Service) {
+	res := axonResult{
+		Valid:   !report.HasErrors(),
+		Issues:  report.Issues,
+		Service: svc,
+	}
+	if res.Issues == nil {
+		res.Issues = []validation.Issue{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !res.Valid {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.ServiceID = r.URL.Query().Get("service")
+	filter.Type = r.URL.Query().Get("type")
+
+	s.writeEvents(w, filter)
+}
+
+func (s *Server) getTimeline(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := s.svcManager.Get(id); err != nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.ServiceID = id
+
+	s.writeEvents(w, filter)
+}
+
+func (s *Server) writeEvents(w http.ResponseWriter, filter models.EventFilter) {
+	events, err := s.svcManager.ListEvents(filter)
+	if err != nil {
+		http.Error(w, "Failed to list events", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(events)
+}
+
+// parseEventFilter reads the shared `since` and `limit` parameters
+func parseEventFilter(r *http.Request) (models.EventFilter, error) {
+	var filter models.EventFilter
+	q := r.URL.Query()
+
+	since, err := parseTimeParam(q.Get("since"), time.Time{})
+	if err != nil {
+		return filter, err
+	}
+	filter.Since = since
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func (s *Server) listInstances(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	instances, err := s.svcManager.Instances(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Service not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to list instances", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(instances)
+}
+
+func (s *Server) deleteInstance(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	instance := chi.URLParam(r, "instance")
+	if err := s.svcManager.DeleteInstance(id, instance); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Instance not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+func (s *Server) listMaintenance(w http.ResponseWriter, r *http.Request) {
+	windows, err := s.svcManager.ListMaintenance()
+	if err != nil {
+		http.Error(w, "Failed to list maintenance windows", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(windows)
+}
+
+func (s *Server) createMaintenance(w http.ResponseWriter, r *http.Request) {
+	var mw models.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&mw); err != nil {
+		http.Error(w, "Invalid maintenance window: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	mw.ID = 0
+	if err := s.svcManager.SaveMaintenance(&mw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(mw)
+}
+
+func (s *Server) updateMaintenance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "mid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid maintenance window id", http.StatusBadRequest)
+		return
+	}
+
+	var mw models.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&mw); err != nil {
+		http.Error(w, "Invalid maintenance window: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	mw.ID = uint(id)
+	if err := s.svcManager.SaveMaintenance(&mw); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Maintenance window not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(mw)
+}
+
+func (s *Server) deleteMaintenance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "mid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid maintenance window id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.svcManager.DeleteMaintenance(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Maintenance window not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+)
+
+// getMetrics serves the service state in the Prometheus text format
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := s.svcManager.WriteMetrics(&buf); err != nil {
+		log.Printf("Failed to export metrics: %v", err)
+		http.Error(w, "Failed to export metrics", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
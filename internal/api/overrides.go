@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+	"gorm.io/gorm"
+)
+
+func (s *Server) getOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	o, err := s.svcManager.GetOverride(id)
+	if err != nil {
+		http.Error(w, "Failed to load override", http.StatusInternalServerError)
+		return
+	}
+	if o == nil {
+		http.Error(w, "Override not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(o)
+}
+
+func (s *Server) putOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var o models.ServiceOverride
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		http.Error(w, "Invalid override: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := s.svcManager.SetOverride(id, &o); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Service not found", http.StatusNotFound)
+			return
+		}
+		var verr *validation.Error
+		if errors.As(err, &verr) {
+			writeRejection(w, err)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(o)
+}
+
+func (s *Server) deleteOverride(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.svcManager.DeleteOverride(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Override not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxPingBody caps how much of a ping body is kept in the log
+const maxPingBody = 10 << 10
+
+func (s *Server) listPingChecks(w http.ResponseWriter, r *http.Request) {
+	checks, err := s.svcManager.ListPingChecks()
+	if err != nil {
+		http.Error(w, "Failed to list ping checks", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(checks)
+}
+
+func (s *Server) createPingCheck(w http.ResponseWriter, r *http.Request) {
+	var c models.PingCheck
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid ping check: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	c.ID = 0
+	if err := s.svcManager.SavePingCheck(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+func (s *Server) updatePingCheck(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "cid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ping check id", http.StatusBadRequest)
+		return
+	}
+
+	var c models.PingCheck
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid ping check: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	c.ID = uint(id)
+	if err := s.svcManager.SavePingCheck(&c); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ping check not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(c)
+}
+
+func (s *Server) rotatePingCheck(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "cid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ping check id", http.StatusBadRequest)
+		return
+	}
+
+	c, err := s.svcManager.RotatePingCheck(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ping check not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(c)
+}
+
+func (s *Server) deletePingCheck(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "cid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ping check id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.svcManager.DeletePingCheck(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Ping check not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ping is the endpoint cron jobs call: /ping/{token}[/start|/fail]
+func (s *Server) ping(w http.ResponseWriter, r *http.Request) {
+	kind := models.PingSuccess
+	switch chi.URLParam(r, "kind") {
+	case "":
+	case "start":
+		kind = models.PingStart
+	case "fail":
+		kind = models.PingFail
+	default:
+		http.Error(w, "Unknown ping (use /start or /fail)", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPingBody))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := s.svcManager.Ping(chi.URLParam(r, "token"), kind, body); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Ping failed: %v", err)
+		writeRejection(w, err)
+		return
+	}
+	w.Write([]byte("OK"))
+}
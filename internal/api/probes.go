@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+func (s *Server) listProbes(w http.ResponseWriter, r *http.Request) {
+	probes, err := s.svcManager.ListProbes(r.URL.Query().Get("service_id"))
+	if err != nil {
+		http.Error(w, "Failed to list probes", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(probes)
+}
+
+func (s *Server) createProbe(w http.ResponseWriter, r *http.Request) {
+	var p models.Probe
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "Invalid probe: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	p.ID = 0
+	p.Auto = false
+	if err := s.svcManager.SaveProbe(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+func (s *Server) updateProbe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "pid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid probe id", http.StatusBadRequest)
+		return
+	}
+
+	var p models.Probe
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "Invalid probe: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	p.ID = uint(id)
+	p.Auto = false
+	if err := s.svcManager.SaveProbe(&p); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Probe not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+func (s *Server) deleteProbe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "pid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid probe id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.svcManager.DeleteProbe(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Probe not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
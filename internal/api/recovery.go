@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/recovery"
+)
+
+func (s *Server) listSnapshots(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	snaps, err := s.svcManager.ListSnapshots(id)
+	if err != nil {
+		http.Error(w, "Failed to list snapshots", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(snaps)
+}
+
+func (s *Server) getRecoveryKit(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	q := r.URL.Query()
+
+	version := 0
+	if v := q.Get("version"); v != "" {
+		var err error
+		if version, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	svc, version, err := s.svcManager.SnapshotService(id, version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	kit, err := recovery.Build(svc, version, q.Get("lang"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-recovery-kit.zip"`, id))
+	if err := kit.WriteZip(w); err != nil {
+		http.Error(w, "Failed to write recovery kit", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+func (s *Server) listScrapeTargets(w http.ResponseWriter, r *http.Request) {
+	targets, err := s.svcManager.ListScrapeTargets()
+	if err != nil {
+		http.Error(w, "Failed to list scrape targets", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(targets)
+}
+
+func (s *Server) createScrapeTarget(w http.ResponseWriter, r *http.Request) {
+	var t models.ScrapeTarget
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid scrape target: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	t.ID = 0
+	if err := s.svcManager.SaveScrapeTarget(&t); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(t)
+}
+
+func (s *Server) updateScrapeTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "tid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid scrape target id", http.StatusBadRequest)
+		return
+	}
+
+	var t models.ScrapeTarget
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid scrape target: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	t.ID = uint(id)
+	if err := s.svcManager.SaveScrapeTarget(&t); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Scrape target not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(t)
+}
+
+func (s *Server) deleteScrapeTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "tid"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid scrape target id", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.svcManager.DeleteScrapeTarget(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Scrape target not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
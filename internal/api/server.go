@@ -2,17 +2,21 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"io/fs"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/wbw1537/synapse/internal/config"
+	"github.com/wbw1537/synapse/internal/models"
 	"github.com/wbw1537/synapse/internal/service"
+	"github.com/wbw1537/synapse/internal/validation"
 )
 
 type Server struct {
@@ -40,18 +44,93 @@ func (s *Server) setupRoutes() {
 	s.router.Use(middleware.Recoverer)
 	s.router.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{"*"}, // Allow all for MVP
-		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders: []string{"X-Next-Cursor"},
 	}))
 
 	// API Routes
 	s.router.Route("/api/v1", func(r chi.Router) {
 		r.Get("/services", s.listServices)
 		r.Get("/services/{id}", s.getService)
+		r.Delete("/services/{id}", s.deleteService)
+		r.Post("/services/{id}/archive", s.archiveService)
+		r.Post("/services/{id}/unarchive", s.unarchiveService)
+		r.Patch("/services/{id}/state", s.updateState)
+		r.Get("/services/{id}/overrides", s.getOverride)
+		r.Put("/services/{id}/overrides", s.putOverride)
+		r.Delete("/services/{id}/overrides", s.deleteOverride)
+		r.Get("/services/{id}/components/{cid}/history", s.getComponentHistory)
+		r.Get("/services/{id}/timeline", s.getTimeline)
+		r.Get("/services/{id}/uptime", s.getUptime)
+		r.Get("/services/{id}/instances", s.listInstances)
+		r.Delete("/services/{id}/instances/{instance}", s.deleteInstance)
+		r.Get("/services/{id}/runs", s.listRuns)
+		r.Post("/services/{id}/runs", s.reportRun)
+		r.Get("/services/{id}/snapshots", s.listSnapshots)
+		r.Get("/services/{id}/recovery-kit", s.getRecoveryKit)
 		r.Post("/services/{id}/actions/{action_id}", s.executeAction)
 		r.Post("/discovery", s.registerService)
+		r.Get("/events", s.listEvents)
+		r.Get("/groups", s.listGroups)
+		r.Get("/groups/{group}/uptime", s.getGroupUptime)
+		r.Get("/topology", s.getTopology)
+		r.Post("/axons/validate", s.validateAxon)
+		r.Post("/axons/import", s.importAxon)
+
+		r.Get("/maintenance", s.listMaintenance)
+		r.Post("/maintenance", s.createMaintenance)
+		r.Put("/maintenance/{mid}", s.updateMaintenance)
+		r.Delete("/maintenance/{mid}", s.deleteMaintenance)
+
+		r.Get("/virtual-services", s.listVirtual)
+		r.Get("/virtual-services/{id}", s.getVirtual)
+		r.Put("/virtual-services/{id}", s.putVirtual)
+		r.Delete("/virtual-services/{id}", s.deleteVirtual)
+
+		r.Post("/hooks/{hook_id}", s.receiveWebhook)
+
+		// Credentials, and anything that makes Core fetch a URL, need the
+		// admin token
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireAdmin)
+			r.Get("/tokens", s.listTokens)
+			r.Post("/tokens", s.createToken)
+			r.Post("/tokens/{tid}/rotate", s.rotateToken)
+			r.Delete("/tokens/{tid}", s.revokeToken)
+
+			r.Get("/probes", s.listProbes)
+			r.Post("/probes", s.createProbe)
+			r.Put("/probes/{pid}", s.updateProbe)
+			r.Delete("/probes/{pid}", s.deleteProbe)
+
+			r.Get("/scrape-targets", s.listScrapeTargets)
+			r.Post("/scrape-targets", s.createScrapeTarget)
+			r.Put("/scrape-targets/{tid}", s.updateScrapeTarget)
+			r.Delete("/scrape-targets/{tid}", s.deleteScrapeTarget)
+
+			r.Get("/ping-checks", s.listPingChecks)
+			r.Post("/ping-checks", s.createPingCheck)
+			r.Put("/ping-checks/{cid}", s.updatePingCheck)
+			r.Post("/ping-checks/{cid}/rotate", s.rotatePingCheck)
+			r.Delete("/ping-checks/{cid}", s.deletePingCheck)
+
+			r.Get("/webhooks", s.listWebhooks)
+			r.Get("/webhooks/{hook_id}", s.getWebhook)
+			r.Put("/webhooks/{hook_id}", s.putWebhook)
+			r.Delete("/webhooks/{hook_id}", s.deleteWebhook)
+		})
 	})
 
+	// Prometheus exporter
+	s.router.Get("/metrics", s.getMetrics)
+
+	// Ping URLs for cron jobs
+	for _, pattern := range []string{"/ping/{token}", "/ping/{token}/{kind}"} {
+		s.router.Get(pattern, s.ping)
+		s.router.Post(pattern, s.ping)
+	}
+
 	// Static Files (Frontend)
 	if s.staticFS != nil {
 		// We expect the FS to be rooted at web/dist
@@ -80,14 +159,62 @@ func (s *Server) Start() error {
 // Handlers
 
 func (s *Server) listServices(w http.ResponseWriter, r *http.Request) {
-	services, err := s.svcManager.List()
+	q := r.URL.Query()
+	filter := models.ServiceFilter{
+		IncludeArchived: q.Get("archived") == "true",
+		Group:           q.Get("group"),
+		Tag:             q.Get("tag"),
+		Query:           q.Get("q"),
+		Sort:            q.Get("sort"),
+		Cursor:          q.Get("cursor"),
+		Summary:         q.Get("fields") == "summary",
+	}
+	if v := q.Get("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid 'limit'", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	services, next, err := s.svcManager.List(filter)
 	if err != nil {
+		var ferr *service.FilterError
+		if errors.As(err, &ferr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to list services", http.StatusInternalServerError)
 		return
 	}
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+
+	if filter.Summary {
+		summaries := make([]models.ServiceSummary, len(services))
+		for i := range services {
+			summaries[i] = models.ServiceSummary{Service: &services[i]}
+		}
+		json.NewEncoder(w).Encode(summaries)
+		return
+	}
 	json.NewEncoder(w).Encode(services)
 }
 
+func (s *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.svcManager.Groups()
+	if err != nil {
+		http.Error(w, "Failed to list groups", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(groups)
+}
+
 func (s *Server) getService(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	svc, err := s.svcManager.Get(id)
@@ -120,11 +247,41 @@ func (s *Server) registerService(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	if err := s.svcManager.Upsert(body); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := s.svcManager.Upsert("", body); err != nil {
+		writeRejection(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+func (s *Server) updateState(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := s.svcManager.UpdateState(id, body); err != nil {
+		writeRejection(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
+
+// writeRejection answers a rejected axon payload with its error document
+func writeRejection(w http.ResponseWriter, err error) {
+	var verr *validation.Error
+	if !errors.As(err, &verr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(verr.Document())
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (s *Server) getTopology(w http.ResponseWriter, r *http.Request) {
+	topo, err := s.svcManager.Topology()
+	if err != nil {
+		http.Error(w, "Failed to build topology", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(topo)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func (s *Server) getUptime(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	window, exclude, err := parseUptimeParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uptime, err := s.svcManager.Uptime(id, window, exclude)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Service not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(uptime)
+}
+
+func (s *Server) getGroupUptime(w http.ResponseWriter, r *http.Request) {
+	group := chi.URLParam(r, "group")
+	window, exclude, err := parseUptimeParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	uptime, err := s.svcManager.GroupUptime(group, window, exclude)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Group not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	json.NewEncoder(w).Encode(uptime)
+}
+
+// parseUptimeParams reads `window` (default 30d) and `exclude_maintenance`
+// (default true)
+func parseUptimeParams(r *http.Request) (string, bool, error) {
+	q := r.URL.Query()
+	window := q.Get("window")
+	if window == "" {
+		window = "30d"
+	}
+
+	exclude := true
+	if v := q.Get("exclude_maintenance"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", false, errors.New("invalid 'exclude_maintenance' (use true or false)")
+		}
+		exclude = b
+	}
+	return window, exclude, nil
+}
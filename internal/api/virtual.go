@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+func (s *Server) listVirtual(w http.ResponseWriter, r *http.Request) {
+	defs, err := s.svcManager.ListVirtual()
+	if err != nil {
+		http.Error(w, "Failed to list virtual services", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(defs)
+}
+
+func (s *Server) getVirtual(w http.ResponseWriter, r *http.Request) {
+	def, err := s.svcManager.GetVirtual(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Virtual service not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(def)
+}
+
+func (s *Server) putVirtual(w http.ResponseWriter, r *http.Request) {
+	var def models.VirtualService
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		http.Error(w, "Invalid virtual service: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	def.ID = chi.URLParam(r, "id")
+	if err := s.svcManager.SaveVirtual(&def); err != nil {
+		writeRejection(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(def)
+}
+
+func (s *Server) deleteVirtual(w http.ResponseWriter, r *http.Request) {
+	if err := s.svcManager.DeleteVirtual(chi.URLParam(r, "id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Virtual service not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxHookBody caps the size of an inbound webhook request
+const maxHookBody = 1 << 20
+
+func (s *Server) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := s.svcManager.ListWebhooks()
+	if err != nil {
+		http.Error(w, "Failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(hooks)
+}
+
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := s.svcManager.GetWebhook(chi.URLParam(r, "hook_id"))
+	if err != nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(hook)
+}
+
+func (s *Server) putWebhook(w http.ResponseWriter, r *http.Request) {
+	var hook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		http.Error(w, "Invalid webhook: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	hook.ID = chi.URLParam(r, "hook_id")
+	if err := s.svcManager.SaveWebhook(&hook); err != nil {
+		writeRejection(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(hook)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := s.svcManager.DeleteWebhook(chi.URLParam(r, "hook_id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// receiveWebhook is the inbound endpoint tools POST to
+func (s *Server) receiveWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHookBody))
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	serviceID, err := s.svcManager.ReceiveWebhook(chi.URLParam(r, "hook_id"), r.Header, r.URL.Query(), body)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		writeRejection(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"service_id": serviceID})
+}
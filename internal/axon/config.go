@@ -0,0 +1,180 @@
+package axon
+
+import (
+	"io"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// SchemaV1 is the axon.toml schema understood by this version of Core
+const SchemaV1 = "axon.card.v1"
+
+// Config mirrors an axon.toml file (see docs/axon_toml_spec.md)
+type Config struct {
+	Schema     string               `toml:"schema"`
+	Meta       Meta                 `toml:"meta"`
+	Layout     Layout               `toml:"layout"`
+	Components map[string]Component `toml:"components"`
+}
+
+// Meta holds the service identity
+type Meta struct {
+	ID          string   `toml:"id"`
+	Name        string   `toml:"name"`
+	Icon        string   `toml:"icon,omitempty"`
+	TTL         int      `toml:"ttl"`
+	Group       string   `toml:"group,omitempty"`
+	Tags        []string `toml:"tags,omitempty"`
+	URL         string   `toml:"url,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Docs        string   `toml:"docs,omitempty"`
+}
+
+// Layout defines the visual hierarchy
+type Layout struct {
+	Type     string    `toml:"type"`
+	Sections []Section `toml:"section"`
+}
+
+// Section is a titled group of component IDs
+type Section struct {
+	Title      string   `toml:"title"`
+	Components []string `toml:"components"`
+}
+
+// Component is a widget definition. Pointer fields distinguish "not set"
+// from zero values where validation needs to know.
+type Component struct {
+	Type       string                 `toml:"type"`
+	Label      string                 `toml:"label,omitempty"`
+	Default    any                    `toml:"default,omitempty"`
+	Unit       string                 `toml:"unit,omitempty"`
+	Copyable   bool                   `toml:"copyable,omitempty"`
+	Min        *float64               `toml:"min,omitempty"`
+	Max        *float64               `toml:"max,omitempty"`
+	Thresholds map[string]string      `toml:"thresholds,omitempty"`
+	Mapping    map[string]StatusState `toml:"mapping,omitempty"`
+	MaxItems   int                    `toml:"max_items,omitzero"`
+	Items      []ActionItem           `toml:"items,omitempty"`
+	URI        string                 `toml:"uri,omitempty"`
+	Text       string                 `toml:"text,omitempty"`
+	Icon       string                 `toml:"icon,omitempty"`
+	Style      string                 `toml:"style,omitempty"`
+	Monitors   []Monitor              `toml:"monitors,omitempty"`
+}
+
+// StatusState is one entry of a status_indicator mapping
+type StatusState struct {
+	Text    string `toml:"text"`
+	Color   string `toml:"color"`
+	Icon    string `toml:"icon,omitempty"`
+	Animate bool   `toml:"animate,omitempty"`
+}
+
+// ActionItem is a button of an action_group
+type ActionItem struct {
+	ID      string `toml:"id"`
+	Label   string `toml:"label"`
+	Style   string `toml:"style,omitempty"`
+	Confirm bool   `toml:"confirm,omitempty"`
+}
+
+// Monitor is a server-side alert rule
+type Monitor struct {
+	Condition string `toml:"condition"`
+	Severity  string `toml:"severity"`
+	Message   string `toml:"message"`
+}
+
+// Encode writes the config as axon.toml
+func (c *Config) Encode(w io.Writer) error {
+	enc := toml.NewEncoder(w)
+	enc.Indent = "    "
+	return enc.Encode(c)
+}
+
+// FromService reconstructs an axon.toml definition from a registered service.
+// The current component values become the defaults. Services registered
+// without a layout get a single section listing every component.
+func FromService(svc *models.Service) *Config {
+	cfg := &Config{
+		Schema: SchemaV1,
+		Meta: Meta{
+			ID:          svc.ID,
+			Name:        svc.Name,
+			Icon:        svc.Icon,
+			TTL:         svc.TTL,
+			Group:       svc.Group,
+			Tags:        svc.Tags,
+			URL:         svc.URL,
+			Description: svc.Description,
+			Docs:        svc.MarkdownDocs,
+		},
+		Layout:     Layout{Type: "sections"},
+		Components: make(map[string]Component, len(svc.Components)),
+	}
+
+	for _, section := range svc.Layout.Root {
+		cfg.Layout.Sections = append(cfg.Layout.Sections, Section{
+			Title:      section.Title,
+			Components: section.Children,
+		})
+	}
+	if len(cfg.Layout.Sections) == 0 && len(svc.Components) > 0 {
+		ids := make([]string, 0, len(svc.Components))
+		for id := range svc.Components {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		cfg.Layout.Sections = []Section{{Title: svc.Name, Components: ids}}
+	}
+
+	for id, comp := range svc.Components {
+		cfg.Components[id] = fromComponent(comp)
+	}
+	return cfg
+}
+
+func fromComponent(comp models.Component) Component {
+	c := Component{
+		Type:       comp.Type,
+		Label:      comp.Label,
+		Unit:       comp.Unit,
+		Copyable:   comp.Copyable,
+		Thresholds: comp.Thresholds,
+		MaxItems:   comp.MaxItems,
+		URI:        comp.URI,
+		Text:       comp.Text,
+		Icon:       comp.Icon,
+		Style:      comp.Style,
+	}
+
+	// Only scalar values make sense as defaults (log_stream history does not)
+	if comp.Type != "log_stream" {
+		switch v := comp.Value.(type) {
+		case string, bool, float64, int, int64:
+			c.Default = v
+		}
+	}
+
+	if comp.Type == "gauge" || comp.Min != 0 || comp.Max != 0 {
+		min, max := comp.Min, comp.Max
+		c.Min, c.Max = &min, &max
+	}
+
+	if len(comp.Mapping) > 0 {
+		c.Mapping = make(map[string]StatusState, len(comp.Mapping))
+		for key, state := range comp.Mapping {
+			c.Mapping[key] = StatusState{Text: state.Text, Color: state.Color, Icon: state.Icon, Animate: state.Animate}
+		}
+	}
+	for _, item := range comp.Items {
+		c.Items = append(c.Items, ActionItem{ID: item.ActionID, Label: item.Label, Style: item.Style, Confirm: item.Confirm})
+	}
+	for _, mon := range comp.Monitors {
+		c.Monitors = append(c.Monitors, Monitor{Condition: mon.Condition, Severity: mon.Severity, Message: mon.Message})
+	}
+	return c
+}
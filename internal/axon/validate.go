@@ -0,0 +1,145 @@
+package axon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+)
+
+// Load parses and validates an axon.toml document. The returned config is
+// nil only if the document could not be parsed at all.
+func Load(data []byte) (*Config, *validation.Report) {
+	report := &validation.Report{}
+
+	var cfg Config
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			report.Errorf(fmt.Sprintf("line %d", perr.Position.Line), "syntax", "%s", perr.Message)
+		} else {
+			// Type mismatches (e.g. ttl = "30") are reported as plain errors
+			report.Errorf("", "syntax", "%v", err)
+		}
+		return nil, report
+	}
+
+	for _, key := range md.Undecoded() {
+		report.Warnf(key.String(), "unknown_key", "unknown key '%s'", key.String())
+	}
+
+	cfg.Validate(report)
+	return &cfg, report
+}
+
+// Validate runs the axon.toml checks from docs/sdk_specification.md
+func (c *Config) Validate(report *validation.Report) {
+	if c.Schema != SchemaV1 {
+		report.Errorf("schema", "schema", "unsupported schema '%s' (expected '%s')", c.Schema, SchemaV1)
+	}
+	if c.Meta.Name == "" {
+		report.Warnf("meta.name", "missing_field", "name is empty, the id will be shown instead")
+	}
+	if c.Meta.TTL <= 0 {
+		report.Errorf("meta.ttl", "missing_field", "ttl must be a positive number of seconds")
+	}
+	if c.Layout.Type != "" && c.Layout.Type != "sections" {
+		report.Errorf("layout.type", "unknown_type", "unsupported layout type '%s' (only 'sections')", c.Layout.Type)
+	}
+
+	validation.CheckService(c.ToService(), validation.StyleTOML, report)
+}
+
+// ToService compiles the definition into the wire model, using the
+// defaults as initial values
+func (c *Config) ToService() *models.Service {
+	svc := &models.Service{
+		ID:           c.Meta.ID,
+		Name:         c.Meta.Name,
+		Group:        c.Meta.Group,
+		Tags:         c.Meta.Tags,
+		Icon:         c.Meta.Icon,
+		URL:          c.Meta.URL,
+		TTL:          c.Meta.TTL,
+		Description:  c.Meta.Description,
+		MarkdownDocs: c.Meta.Docs,
+		APIVersion:   "v1",
+		Layout:       models.LayoutSchema{Type: c.Layout.Type},
+		Components:   make(map[string]models.Component, len(c.Components)),
+	}
+	if svc.Layout.Type == "" {
+		svc.Layout.Type = "sections"
+	}
+
+	for _, section := range c.Layout.Sections {
+		svc.Layout.Root = append(svc.Layout.Root, models.LayoutSection{
+			Type:     "section",
+			Title:    section.Title,
+			Children: section.Components,
+		})
+	}
+
+	for id, comp := range c.Components {
+		svc.Components[id] = comp.toModel(id)
+	}
+	return svc
+}
+
+func (c Component) toModel(id string) models.Component {
+	comp := models.Component{
+		ID:         id,
+		Type:       c.Type,
+		Label:      c.Label,
+		Value:      c.Default,
+		Unit:       c.Unit,
+		Copyable:   c.Copyable,
+		Thresholds: c.Thresholds,
+		MaxItems:   c.MaxItems,
+		URI:        c.URI,
+		Text:       c.Text,
+		Icon:       c.Icon,
+		Style:      c.Style,
+	}
+	if c.Min != nil {
+		comp.Min = *c.Min
+	}
+	if c.Max != nil {
+		comp.Max = *c.Max
+	}
+
+	// TOML integers decode as int64; keep numbers uniform with JSON payloads
+	if n, ok := comp.Value.(int64); ok {
+		comp.Value = float64(n)
+	}
+
+	if len(c.Mapping) > 0 {
+		comp.Mapping = make(map[string]models.StatusState, len(c.Mapping))
+		for key, state := range c.Mapping {
+			comp.Mapping[key] = models.StatusState{Text: state.Text, Color: state.Color, Icon: state.Icon, Animate: state.Animate}
+		}
+	}
+	for _, item := range c.Items {
+		comp.Items = append(comp.Items, models.ActionGroupItem{ActionID: item.ID, Label: item.Label, Style: item.Style, Confirm: item.Confirm})
+	}
+	for _, mon := range c.Monitors {
+		comp.Monitors = append(comp.Monitors, models.Monitor{Condition: mon.Condition, Severity: mon.Severity, Message: mon.Message})
+	}
+	return comp
+}
+
+// FormatIssues renders a report as "path: severity: message" lines
+func FormatIssues(report *validation.Report) string {
+	var b strings.Builder
+	for _, issue := range report.Issues {
+		path := issue.Path
+		if path == "" {
+			path = "(document)"
+		}
+		fmt.Fprintf(&b, "%s: %s: %s\n", path, issue.Severity, issue.Message)
+	}
+	return b.String()
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 )
@@ -18,7 +19,8 @@ type Config struct {
 	WSPort   string `env:"SYNAPSE_WS_PORT" envDefault:":8083"` // WebSocket for UI
 
 	// Security
-	AuthToken string `env:"SYNAPSE_AUTH_TOKEN" envDefault:"synapse-secret"`
+	AuthToken        string `env:"SYNAPSE_AUTH_TOKEN" envDefault:"synapse-secret"`
+	AllowGlobalToken bool   `env:"SYNAPSE_ALLOW_GLOBAL_TOKEN" envDefault:"true"` // Accept AuthToken for every service (bootstrap/legacy)
 
 	// Notification (SMTP)
 	SMTPHost     string `env:"SYNAPSE_SMTP_HOST"`
@@ -26,8 +28,19 @@ type Config struct {
 	SMTPUser     string `env:"SYNAPSE_SMTP_USER"`
 	SMTPPass     string `env:"SYNAPSE_SMTP_PASS"`
 	SMTPFrom     string `env:"SYNAPSE_SMTP_FROM" envDefault:"synapse@localhost"`
-	SMTPTo       string `env:"SYNAPSE_SMTP_TO"`       // Comma separated list
+	SMTPTo       string `env:"SYNAPSE_SMTP_TO"` // Comma separated list
 	EnableAlerts bool   `env:"SYNAPSE_ENABLE_ALERTS" envDefault:"false"`
+
+	// Lifecycle
+	ArchiveAfter time.Duration `env:"SYNAPSE_ARCHIVE_AFTER" envDefault:"0"` // Auto-archive services offline this long (0 = never)
+
+	// Probes
+	ProbeServiceURLs bool `env:"SYNAPSE_PROBE_URLS" envDefault:"false"` // Probe every service URL over HTTP
+
+	// History (numeric component values)
+	HistoryRawRetention    time.Duration `env:"SYNAPSE_HISTORY_RAW_RETENTION" envDefault:"24h"`
+	HistoryMinuteRetention time.Duration `env:"SYNAPSE_HISTORY_1M_RETENTION" envDefault:"168h"`  // 7 days
+	HistoryHourRetention   time.Duration `env:"SYNAPSE_HISTORY_1H_RETENTION" envDefault:"2160h"` // 90 days
 }
 
 func Load() *Config {
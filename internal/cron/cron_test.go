@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// 2026-01-14 is a Wednesday
+	from := time.Date(2026, 1, 14, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		want []string // The next matches after from, in order
+	}{
+		{"every minute", "* * * * *", []string{"2026-01-14T10:08:00Z", "2026-01-14T10:09:00Z"}},
+		{"range", "0 9-11 * * *", []string{"2026-01-14T11:00:00Z", "2026-01-15T09:00:00Z", "2026-01-15T10:00:00Z"}},
+		{"step", "*/20 * * * *", []string{"2026-01-14T10:20:00Z", "2026-01-14T10:40:00Z", "2026-01-14T11:00:00Z"}},
+		{"range with step", "0-30/15 10 * * *", []string{"2026-01-14T10:15:00Z", "2026-01-14T10:30:00Z", "2026-01-15T10:00:00Z"}},
+		{"value with step", "50/5 10 * * *", []string{"2026-01-14T10:50:00Z", "2026-01-14T10:55:00Z", "2026-01-15T10:50:00Z"}},
+		{"list", "5,10 12,18 * * *", []string{"2026-01-14T12:05:00Z", "2026-01-14T12:10:00Z", "2026-01-14T18:05:00Z"}},
+		{"day of week", "0 8 * * mon-fri", []string{"2026-01-15T08:00:00Z", "2026-01-16T08:00:00Z", "2026-01-19T08:00:00Z"}},
+		{"sunday as 7", "0 0 * * 7", []string{"2026-01-18T00:00:00Z", "2026-01-25T00:00:00Z"}},
+		{"day of month or day of week", "0 0 20 * sat", []string{"2026-01-17T00:00:00Z", "2026-01-20T00:00:00Z", "2026-01-24T00:00:00Z"}},
+		{"day of month with any day of week", "0 0 20 * *", []string{"2026-01-20T00:00:00Z", "2026-02-20T00:00:00Z"}},
+		{"month rollover", "0 0 31 * *", []string{"2026-01-31T00:00:00Z", "2026-03-31T00:00:00Z", "2026-05-31T00:00:00Z"}},
+		{"year rollover", "0 0 1 jan *", []string{"2027-01-01T00:00:00Z", "2028-01-01T00:00:00Z"}},
+		{"leap day", "0 0 29 2 *", []string{"2028-02-29T00:00:00Z"}},
+		{"macro", "@monthly", []string{"2026-02-01T00:00:00Z", "2026-03-01T00:00:00Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			at := from
+			for _, want := range tt.want {
+				at = s.Next(at)
+				if got := at.Format(time.RFC3339); got != want {
+					t.Fatalf("Next = %s, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Fatalf("Next = %s, want zero time", next)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
@@ -41,7 +41,23 @@ func Connect(dbPath string) (*Database, error) {
 
 func (d *Database) InitSchema() error {
 	// AutoMigrate creates tables, missing columns, and indexes automatically
-	err := d.Conn.AutoMigrate(&models.Service{})
+	err := d.Conn.AutoMigrate(
+		&models.Service{},
+		&models.ServiceOverride{},
+		&models.ComponentSample{},
+		&models.ComponentRollup{},
+		&models.Event{},
+		&models.MaintenanceWindow{},
+		&models.ServiceSnapshot{},
+		&models.AxonToken{},
+		&models.ServiceInstance{},
+		&models.VirtualService{},
+		&models.Probe{},
+		&models.ScrapeTarget{},
+		&models.Webhook{},
+		&models.PingCheck{},
+		&models.JobRun{},
+	)
 	if err != nil {
 		return fmt.Errorf("failed to auto-migrate schema: %w", err)
 	}
@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/expr-lang/expr/vm"
+	"github.com/wbw1537/synapse/internal/metrics"
+)
+
+// maxPrograms bounds the program cache. It is dropped as a whole when full,
+// which only happens if conditions keep changing.
+const maxPrograms = 4096
+
+// programKey identifies a compiled program. expr type-checks against the
+// environment, so the same source compiles to a different program for each
+// kind of environment and each type of the value it is evaluated on.
+type programKey struct {
+	env    string       // Kind of environment: monitor, virtual, webhook
+	source string       // Expression text
+	shape  reflect.Type // Type of the variable part of the environment
+}
+
+// cachedProgram is a compiled program, or the error compiling it gave
+type cachedProgram struct {
+	program *vm.Program
+	err     error
+}
+
+var (
+	programs   = make(map[programKey]cachedProgram)
+	programsMu sync.RWMutex
+)
+
+// compile returns the cached program for key, compiling it on first use.
+// Compile errors are cached too, so a broken condition is not recompiled on
+// every message.
+func compile(key programKey, fn func() (*vm.Program, error)) (*vm.Program, error) {
+	programsMu.RLock()
+	cached, ok := programs[key]
+	programsMu.RUnlock()
+	if ok {
+		return cached.program, cached.err
+	}
+
+	program, err := fn()
+	metrics.ExprCompiles.Inc()
+
+	programsMu.Lock()
+	if len(programs) >= maxPrograms {
+		programs = make(map[programKey]cachedProgram)
+	}
+	programs[key] = cachedProgram{program: program, err: err}
+	programsMu.Unlock()
+	return program, err
+}
+
+// CachedPrograms returns the number of compiled programs in the cache
+func CachedPrograms() int {
+	programsMu.RLock()
+	defer programsMu.RUnlock()
+	return len(programs)
+}
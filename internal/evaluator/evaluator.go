@@ -2,20 +2,133 @@ package evaluator
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/types"
+	"github.com/expr-lang/expr/vm"
 )
 
-// Evaluate checks if the condition is true given the value
-func Evaluate(condition string, value any) (bool, error) {
-	// 1. Compile the expression
-	program, err := expr.Compile(condition, expr.Env(map[string]any{"value": value}))
+// ComponentEnv is a component as seen by a monitor condition: the one it is
+// defined on (value, min, ...) or a sibling (components.load.value)
+type ComponentEnv struct {
+	Value      any
+	Min        float64
+	Max        float64
+	Unit       string
+	Thresholds map[string]string
+}
+
+// ServiceEnv is the service as seen by a monitor condition (service.group)
+type ServiceEnv struct {
+	ID    string
+	Name  string
+	Group string
+	Tags  []string
+}
+
+// componentType and serviceType declare the fixed part of the monitor
+// environment, so e.g. a misspelled field fails to compile. They are maps at
+// run time: expr cannot resolve struct fields declared in a types.Map.
+var (
+	componentType = types.Map{
+		"value":      types.Any,
+		"min":        types.Float64,
+		"max":        types.Float64,
+		"unit":       types.String,
+		"thresholds": types.TypeOf(map[string]string(nil)),
+	}
+	serviceType = types.Map{
+		"id":    types.String,
+		"name":  types.String,
+		"group": types.String,
+		"tags":  types.TypeOf([]string(nil)),
+	}
+)
+
+// monitorTypes declares the monitor environment for the compiler. value is
+// typed by the reported value, prev may be nil and is untyped.
+func monitorTypes(value types.Type) types.Map {
+	return types.Map{
+		"value":      value,
+		"prev":       types.Any,
+		"min":        types.Float64,
+		"max":        types.Float64,
+		"unit":       types.String,
+		"thresholds": types.TypeOf(map[string]string(nil)),
+		"components": types.Map{types.Extra: componentType},
+		"service":    serviceType,
+	}
+}
+
+// MonitorEnv is the environment of the monitor conditions of a service.
+// It is built once per evaluation of the service and pointed at each
+// component with For.
+type MonitorEnv struct {
+	vars map[string]any
+}
+
+// NewMonitorEnv returns the environment of a service and its components
+func NewMonitorEnv(svc ServiceEnv, components map[string]ComponentEnv) *MonitorEnv {
+	comps := make(map[string]any, len(components))
+	for id, c := range components {
+		comps[id] = componentVars(c, make(map[string]any, 5))
+	}
+	tags := svc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return &MonitorEnv{vars: map[string]any{
+		"components": comps,
+		"service": map[string]any{
+			"id":    svc.ID,
+			"name":  svc.Name,
+			"group": svc.Group,
+			"tags":  tags,
+		},
+	}}
+}
+
+// For points the environment at the component whose monitors are evaluated
+// next. prev is its value at the previous report, nil if unknown.
+func (e *MonitorEnv) For(c ComponentEnv, prev any) *MonitorEnv {
+	componentVars(c, e.vars)
+	e.vars["prev"] = prev
+	return e
+}
+
+func componentVars(c ComponentEnv, vars map[string]any) map[string]any {
+	thresholds := c.Thresholds
+	if thresholds == nil {
+		thresholds = map[string]string{}
+	}
+	vars["value"] = c.Value
+	vars["min"] = c.Min
+	vars["max"] = c.Max
+	vars["unit"] = c.Unit
+	vars["thresholds"] = thresholds
+	return vars
+}
+
+// Evaluate checks if the condition is true in the given environment. The
+// program is compiled once per condition and value type, see compile.
+func Evaluate(condition string, env *MonitorEnv) (bool, error) {
+	// 1. Compile the expression, typed by the value
+	value := env.vars["value"]
+	key := programKey{env: "monitor", source: condition, shape: reflect.TypeOf(value)}
+	program, err := compile(key, func() (*vm.Program, error) {
+		var valueType types.Type = types.Any
+		if value != nil {
+			valueType = types.TypeOf(value)
+		}
+		return expr.Compile(condition, expr.Env(monitorTypes(valueType)), expr.AsBool())
+	})
 	if err != nil {
 		return false, fmt.Errorf("invalid condition '%s': %w", condition, err)
 	}
 
 	// 2. Run the expression
-	output, err := expr.Run(program, map[string]any{"value": value})
+	output, err := expr.Run(program, env.vars)
 	if err != nil {
 		return false, fmt.Errorf("execution failed: %w", err)
 	}
@@ -28,3 +141,20 @@ func Evaluate(condition string, value any) (bool, error) {
 
 	return result, nil
 }
+
+// Validate checks that a condition compiles and yields a boolean.
+// The value type is unknown at this point, so value is untyped; the rest of
+// the environment is checked. The result is cached, as axons re-send their
+// conditions with every discovery payload.
+func Validate(condition string) error {
+	if condition == "" {
+		return fmt.Errorf("condition is empty")
+	}
+	_, err := compile(programKey{env: "validate", source: condition}, func() (*vm.Program, error) {
+		return expr.Compile(condition, expr.Env(monitorTypes(types.Any)), expr.AsBool())
+	})
+	if err != nil {
+		return fmt.Errorf("invalid condition '%s': %w", condition, err)
+	}
+	return nil
+}
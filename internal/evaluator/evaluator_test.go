@@ -0,0 +1,85 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/types"
+)
+
+// heartbeat is the monitors of a typical service and the values of one
+// heartbeat
+var heartbeat = []struct {
+	condition string
+	value     any
+}{
+	{"value > 90", 42.5},
+	{"value > 80 && value <= 90", 42.5},
+	{"value < 10", 1024.0},
+	{"value == 'down'", "up"},
+	{"value in ['degraded', 'down']", "up"},
+	{"value != true", true},
+	{"len(value) > 0 && value[0] contains 'ERROR'", []any{"backup done"}},
+	{"value.used / value.total > 0.9", map[string]any{"used": 700.0, "total": 1000.0}},
+}
+
+// ingestUncached is the ingest path before the program cache: the
+// conditions are validated and then compiled for every heartbeat
+func ingestUncached(b *testing.B) {
+	for _, m := range heartbeat {
+		if _, err := expr.Compile(m.condition, expr.Env(monitorTypes(types.Any)), expr.AsBool()); err != nil {
+			b.Fatal(err)
+		}
+		env := NewMonitorEnv(ServiceEnv{}, nil).For(ComponentEnv{Value: m.value}, nil)
+		program, err := expr.Compile(m.condition, expr.Env(monitorTypes(types.TypeOf(m.value))), expr.AsBool())
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := expr.Run(program, env.vars); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func ingestCached(b *testing.B) {
+	for _, m := range heartbeat {
+		if err := Validate(m.condition); err != nil {
+			b.Fatal(err)
+		}
+		env := NewMonitorEnv(ServiceEnv{}, nil).For(ComponentEnv{Value: m.value}, nil)
+		if _, err := Evaluate(m.condition, env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkIngest measures the monitor work of one heartbeat
+func BenchmarkIngest(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			ingestUncached(b)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			ingestCached(b)
+		}
+	})
+}
+
+// BenchmarkEvaluateParallel measures cache contention with many services
+// reporting at once
+func BenchmarkEvaluateParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			m := heartbeat[i%len(heartbeat)]
+			env := NewMonitorEnv(ServiceEnv{}, nil).For(ComponentEnv{Value: m.value}, nil)
+			if _, err := Evaluate(m.condition, env); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package evaluator
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+	"github.com/expr-lang/expr/vm"
+)
+
+// virtualEnv types services as a map so any lookup compiles
+var virtualEnv = map[string]any{"services": map[string]any{}}
+
+// Inputs checks that a virtual component expression compiles and returns the
+// service IDs it reads. Services must be looked up with a constant ID, so
+// Core knows which updates to re-evaluate it on.
+func Inputs(expression string) ([]string, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	tree, err := parser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", expression, err)
+	}
+
+	v := &inputVisitor{}
+	ast.Walk(&tree.Node, v)
+	if v.refs != len(v.inputs) {
+		return nil, fmt.Errorf("invalid expression '%s': services must be indexed with a constant ID, e.g. services[\"nas\"]", expression)
+	}
+	if _, err := expr.Compile(expression, expr.Env(virtualEnv)); err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", expression, err)
+	}
+
+	slices.Sort(v.inputs)
+	return slices.Compact(v.inputs), nil
+}
+
+// inputVisitor collects the constant lookups into services
+type inputVisitor struct {
+	inputs []string
+	refs   int // Every use of services, constant lookup or not
+}
+
+func (v *inputVisitor) Visit(node *ast.Node) {
+	switch n := (*node).(type) {
+	case *ast.IdentifierNode:
+		if n.Value == "services" {
+			v.refs++
+		}
+	case *ast.MemberNode:
+		id, ok := n.Node.(*ast.IdentifierNode)
+		if !ok || id.Value != "services" {
+			return
+		}
+		if prop, ok := n.Property.(*ast.StringNode); ok {
+			v.inputs = append(v.inputs, prop.Value)
+		}
+	}
+}
+
+// Compute evaluates a virtual component expression. services maps service
+// IDs to their state as built by the service manager.
+func Compute(expression string, services map[string]any) (any, error) {
+	env := map[string]any{"services": services}
+	program, err := compile(programKey{env: "virtual", source: expression}, func() (*vm.Program, error) {
+		return expr.Compile(expression, expr.Env(virtualEnv))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", expression, err)
+	}
+	output, err := expr.Run(program, env)
+	if err != nil {
+		return nil, fmt.Errorf("execution failed: %w", err)
+	}
+	return output, nil
+}
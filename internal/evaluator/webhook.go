@@ -0,0 +1,42 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Request is the environment of webhook mapping expressions
+type Request struct {
+	Body    any               `expr:"body"`    // Decoded JSON, or the raw text if the body is not JSON
+	Headers map[string]string `expr:"headers"` // Lower-cased names
+	Query   map[string]string `expr:"query"`
+}
+
+// CheckExtract checks that a webhook mapping expression compiles. The body
+// is untyped, so only the structure is checked.
+func CheckExtract(expression string) error {
+	if expression == "" {
+		return fmt.Errorf("expression is empty")
+	}
+	if _, err := expr.Compile(expression, expr.Env(Request{})); err != nil {
+		return fmt.Errorf("invalid expression '%s': %w", expression, err)
+	}
+	return nil
+}
+
+// Extract evaluates a webhook mapping expression against a request
+func Extract(expression string, req Request) (any, error) {
+	program, err := compile(programKey{env: "webhook", source: expression}, func() (*vm.Program, error) {
+		return expr.Compile(expression, expr.Env(Request{}))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%s': %w", expression, err)
+	}
+	output, err := expr.Run(program, req)
+	if err != nil {
+		return nil, fmt.Errorf("execution failed: %w", err)
+	}
+	return output, nil
+}
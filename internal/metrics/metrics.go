@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing internal counter, exported as a
+// Prometheus counter
+type Counter struct {
+	Name  string
+	Help  string
+	value atomic.Uint64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Value returns the current count
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// Internal counters of Core
+var (
+	Upserts          = &Counter{Name: "synapse_upserts_total", Help: "Discovery payloads processed."}
+	UpsertErrors     = &Counter{Name: "synapse_upsert_errors_total", Help: "Discovery payloads rejected or failed."}
+	ActionsPublished = &Counter{Name: "synapse_actions_published_total", Help: "Actions published to axons."}
+	EmailsSent       = &Counter{Name: "synapse_emails_sent_total", Help: "Alert emails sent."}
+	ExprCompiles     = &Counter{Name: "synapse_expr_compiles_total", Help: "Expressions compiled, cache misses of the program cache."}
+)
+
+var counters = []*Counter{Upserts, UpsertErrors, ActionsPublished, EmailsSent, ExprCompiles}
+
+// WriteCounters writes the internal counters in the Prometheus text format
+func WriteCounters(w io.Writer) {
+	for _, c := range counters {
+		WriteHeader(w, c.Name, "counter", c.Help)
+		WriteSample(w, c.Name, nil, float64(c.Value()))
+	}
+}
+
+// WriteHeader writes the HELP and TYPE lines of a metric family
+func WriteHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+// WriteSample writes a single series. labels holds name/value pairs.
+func WriteSample(w io.Writer, name string, labels []string, value float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(escapeLabel(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatValue(value))
+	b.WriteByte('\n')
+	io.WriteString(w, b.String())
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
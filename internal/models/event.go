@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Event types written to the event log
+const (
+	EventRegistered       = "registered"
+	EventStatusChanged    = "status_changed"
+	EventMonitorFired     = "monitor_fired"
+	EventMonitorResolved  = "monitor_resolved"
+	EventHeartbeatMissed  = "heartbeat_missed"
+	EventHeartbeatResumed = "heartbeat_resumed"
+	EventActionExecuted   = "action_executed"
+	EventArchived         = "archived"
+	EventUnarchived       = "unarchived"
+	EventDeleted          = "deleted"
+)
+
+// Event is a persisted entry of the per-service timeline
+type Event struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ServiceID   string    `gorm:"index" json:"service_id"`
+	Type        string    `gorm:"index" json:"type"`
+	Severity    string    `json:"severity,omitempty"`
+	Status      string    `json:"status,omitempty"`      // New status (status_changed)
+	PrevStatus  string    `json:"prev_status,omitempty"` // Old status (status_changed)
+	ComponentID string    `json:"component_id,omitempty"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// EventFilter narrows down an event log query
+type EventFilter struct {
+	ServiceID string
+	Type      string
+	Since     time.Time
+	Limit     int
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// History resolutions. Raw samples are rolled up into fixed buckets on write.
+const (
+	ResolutionRaw    = "raw"
+	ResolutionMinute = "1m"
+	ResolutionHour   = "1h"
+)
+
+// ComponentSample is a single raw reading of a numeric component
+type ComponentSample struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	ServiceID   string    `gorm:"index:idx_sample_series,priority:1" json:"service_id"`
+	ComponentID string    `gorm:"index:idx_sample_series,priority:2" json:"component_id"`
+	Timestamp   time.Time `gorm:"index:idx_sample_series,priority:3;index" json:"timestamp"`
+	Value       float64   `json:"value"`
+}
+
+// ComponentRollup aggregates the samples of one component into a time bucket
+type ComponentRollup struct {
+	ServiceID   string    `gorm:"primaryKey" json:"service_id"`
+	ComponentID string    `gorm:"primaryKey" json:"component_id"`
+	Resolution  string    `gorm:"primaryKey" json:"resolution"` // 1m, 1h
+	Bucket      time.Time `gorm:"primaryKey;index" json:"bucket"`
+	Min         float64   `gorm:"column:min_value" json:"min"`
+	Max         float64   `gorm:"column:max_value" json:"max"`
+	Sum         float64   `gorm:"column:sum_value" json:"sum"`
+	Count       int64     `gorm:"column:sample_count" json:"count"`
+}
+
+// HistoryPoint is one bucket of a history query
+type HistoryPoint struct {
+	Time  time.Time `json:"time"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Avg   float64   `json:"avg"`
+	Count int64     `json:"count"`
+}
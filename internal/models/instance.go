@@ -0,0 +1,100 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Aggregation rules for the status of multi-instance services
+const (
+	AggregateAll    = "all"    // Worst instance status wins (default)
+	AggregateAny    = "any"    // Best instance status wins
+	AggregateQuorum = "quorum" // Best status held by a majority of instances
+)
+
+// Aggregation rules for numeric component values
+const (
+	AggregateSum  = "sum"
+	AggregateAvg  = "avg" // Default for numbers
+	AggregateMin  = "min"
+	AggregateMax  = "max"
+	AggregateLast = "last" // Value of the most recently seen instance (default for non-numbers)
+)
+
+// Aggregation configures how the instances of a service are combined
+type Aggregation struct {
+	Status     string            `json:"status,omitempty"`
+	Components map[string]string `json:"components,omitempty"` // Key: component ID
+}
+
+// ServiceInstance is one replica of a logical service. Every instance has its
+// own TTL and component values; the service shows the aggregate.
+type ServiceInstance struct {
+	ServiceID string         `gorm:"primaryKey" json:"-"`
+	Instance  string         `gorm:"primaryKey" json:"instance"`
+	Status    string         `json:"status"` // As reported, "offline" once the TTL expired
+	Message   string         `json:"message,omitempty"`
+	TTL       int            `json:"ttl"`
+	Values    map[string]any `gorm:"serializer:json" json:"values"` // Key: component ID
+	LastSeen  time.Time      `json:"last_seen"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// aggregateRank orders instance statuses from best to worst
+var aggregateRank = map[string]int{
+	"online":      0,
+	"warning":     1,
+	"maintenance": 2,
+	"error":       3,
+	"impacted":    3,
+	"offline":     4,
+}
+
+// AggregateStatus combines instance statuses according to rule
+func AggregateStatus(rule string, statuses []string) string {
+	if len(statuses) == 0 {
+		return "offline"
+	}
+	sorted := append([]string(nil), statuses...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return aggregateRank[sorted[i]] < aggregateRank[sorted[j]]
+	})
+
+	switch rule {
+	case AggregateAny:
+		return sorted[0]
+	case AggregateQuorum:
+		// The majority-th best status: at least n/2+1 instances are this good
+		return sorted[len(sorted)/2]
+	default:
+		return sorted[len(sorted)-1]
+	}
+}
+
+// AggregateNumbers combines numeric instance values according to rule
+func AggregateNumbers(rule string, values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	result := values[0]
+	switch rule {
+	case AggregateSum, AggregateAvg, "":
+		result = 0
+		for _, v := range values {
+			result += v
+		}
+		if rule != AggregateSum {
+			result /= float64(len(values))
+		}
+	case AggregateMin:
+		for _, v := range values[1:] {
+			result = min(result, v)
+		}
+	case AggregateMax:
+		for _, v := range values[1:] {
+			result = max(result, v)
+		}
+	}
+	return result
+}
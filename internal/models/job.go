@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Job run statuses
+const (
+	RunRunning = "running"
+	RunSuccess = "success"
+	RunFailed  = "failed"
+	RunMissed  = "missed" // No run started around a scheduled time
+)
+
+// Job states, see JobState
+const (
+	JobOK       = "ok"
+	JobRunning  = "running"
+	JobFailed   = "failed"
+	JobMissed   = "missed"
+	JobOverlong = "overlong"
+)
+
+// JobSchedule declares when a job service is expected to run. It replaces
+// the TTL model for jobs that run daily or weekly.
+type JobSchedule struct {
+	Cron        string `json:"cron"`                   // minute hour day-of-month month day-of-week, or @daily etc.
+	Timezone    string `json:"timezone,omitempty"`     // IANA name, default UTC
+	Grace       int    `json:"grace,omitempty"`        // Seconds a run may start late, default 600
+	MaxDuration int    `json:"max_duration,omitempty"` // Seconds after which a run is overlong, 0 for no limit
+}
+
+// GraceOrDefault returns the grace period in seconds
+func (s *JobSchedule) GraceOrDefault() int {
+	if s.Grace > 0 {
+		return s.Grace
+	}
+	return 600
+}
+
+// JobState is the job health Core derives from the runs of a service. A
+// failed, missed or overlong job fires like a monitor.
+type JobState struct {
+	State   string     `json:"state"` // ok, running, failed, missed, overlong
+	Message string     `json:"message,omitempty"`
+	LastRun *time.Time `json:"last_run,omitempty"` // Start of the latest run
+	NextRun *time.Time `json:"next_run,omitempty"` // Next scheduled time
+	Checked *time.Time `json:"checked,omitempty"`  // Last scheduled time checked for a missed run
+}
+
+// JobRun is a single run of a job, stored in job_runs
+type JobRun struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ServiceID   string     `gorm:"index" json:"service_id"`
+	RunID       string     `gorm:"index" json:"run_id,omitempty"` // Set by the reporter to match the end to its start
+	Status      string     `json:"status"`                        // running, success, failed, missed
+	Overlong    bool       `json:"overlong,omitempty"`            // Ran past the schedule's max_duration
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`        // Missed runs only
+	StartedAt   *time.Time `gorm:"index" json:"started_at,omitempty"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+	Duration    *float64   `json:"duration,omitempty"` // Seconds
+	ExitCode    *int       `json:"exit_code,omitempty"`
+	Output      string     `json:"output,omitempty"` // Tail of the output
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// RunPayload reports the start or end of a job run, via
+// POST /services/{id}/runs or synapse/v1/run/{id}
+type RunPayload struct {
+	AuthToken string     `json:"auth_token"`
+	RunID     string     `json:"run_id,omitempty"`
+	Event     string     `json:"event,omitempty"`      // start or end (default)
+	StartedAt *time.Time `json:"started_at,omitempty"` // End without a start: when the run began
+	Duration  *float64   `json:"duration,omitempty"`   // End without a start: seconds the run took
+	ExitCode  *int       `json:"exit_code,omitempty"`  // End: 0 (default) is success
+	Output    string     `json:"output,omitempty"`     // End: output, only the tail is kept
+}
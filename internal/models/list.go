@@ -0,0 +1,30 @@
+package models
+
+// ServiceFilter narrows down and pages a service list query
+type ServiceFilter struct {
+	IncludeArchived bool
+	Group           string
+	Tag             string
+	Statuses        []string
+	Query           string // Case-insensitive match on name or ID
+	Sort            string // name, id, group, status, last_seen; "-" prefix for descending
+	Cursor          string // Opaque, from the previous page
+	Limit           int    // 0 returns all matches
+	Summary         bool   // Skip components, layout and markdown_docs
+}
+
+// ServiceSummary is the light representation of a service for large lists.
+// The shadowing nil fields hide the heavy parts of the embedded Service.
+type ServiceSummary struct {
+	*Service
+	Layout       *struct{} `json:"layout,omitempty"`
+	Components   *struct{} `json:"components,omitempty"`
+	MarkdownDocs *struct{} `json:"markdown_docs,omitempty"`
+}
+
+// GroupSummary counts the services of a group per status
+type GroupSummary struct {
+	Name     string         `json:"name"`
+	Total    int            `json:"total"`
+	Statuses map[string]int `json:"statuses"`
+}
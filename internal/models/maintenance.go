@@ -0,0 +1,184 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Maintenance recurrences
+const (
+	RecurrenceNone   = ""
+	RecurrenceDaily  = "daily"
+	RecurrenceWeekly = "weekly"
+)
+
+// MaintenanceWindow silences alerts for the services in its scope.
+// Start/End describe the first occurrence; recurring windows repeat it daily
+// or weekly until Until. A window without any scope applies to all services.
+type MaintenanceWindow struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `json:"name"`
+	ServiceIDs []string   `gorm:"serializer:json" json:"service_ids,omitempty"`
+	Groups     []string   `gorm:"serializer:json" json:"groups,omitempty"`
+	Tags       []string   `gorm:"serializer:json" json:"tags,omitempty"`
+	Start      time.Time  `json:"start"`
+	End        time.Time  `json:"end"`
+	Recurrence string     `json:"recurrence,omitempty"` // "", daily, weekly
+	Timezone   string     `json:"timezone,omitempty"`   // IANA name whose wall-clock time recurrences keep, default UTC
+	Until      *time.Time `json:"until,omitempty"`
+
+	Active bool `gorm:"-" json:"active"` // Computed at read time
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaintenanceState is attached to a service while a window covers it
+type MaintenanceState struct {
+	WindowID uint      `json:"window_id"`
+	Name     string    `json:"name"`
+	Ends     time.Time `json:"ends"`
+}
+
+// Interval is a half-open time range [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Validate checks that the window describes a usable schedule
+func (w *MaintenanceWindow) Validate() error {
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone '%s'", w.Timezone)
+	}
+	switch w.Recurrence {
+	case RecurrenceNone:
+	case RecurrenceDaily, RecurrenceWeekly:
+		if w.End.Sub(w.Start) >= w.period() {
+			return fmt.Errorf("a %s window must be shorter than its period", w.Recurrence)
+		}
+	default:
+		return fmt.Errorf("unknown recurrence '%s' (use daily or weekly)", w.Recurrence)
+	}
+	return nil
+}
+
+// Matches reports whether the service is in the window's scope
+func (w *MaintenanceWindow) Matches(svc *Service) bool {
+	if len(w.ServiceIDs) == 0 && len(w.Groups) == 0 && len(w.Tags) == 0 {
+		return true
+	}
+	for _, id := range w.ServiceIDs {
+		if id == svc.ID {
+			return true
+		}
+	}
+	for _, group := range w.Groups {
+		if group == svc.Group {
+			return true
+		}
+	}
+	for _, tag := range w.Tags {
+		for _, svcTag := range svc.Tags {
+			if tag == svcTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActiveAt returns the end of the occurrence covering t, if any.
+// Occurrences are clipped to Until like in Occurrences.
+func (w *MaintenanceWindow) ActiveAt(t time.Time) (time.Time, bool) {
+	if t.Before(w.Start) {
+		return time.Time{}, false
+	}
+
+	start := w.Start
+	if w.Recurrence != RecurrenceNone {
+		start = w.occurrenceBefore(t)
+	}
+
+	end := start.Add(w.End.Sub(w.Start))
+	if w.Recurrence != RecurrenceNone && w.Until != nil {
+		if start.After(*w.Until) {
+			return time.Time{}, false
+		}
+		if end.After(*w.Until) {
+			end = *w.Until
+		}
+	}
+	if t.Before(end) {
+		return end, true
+	}
+	return time.Time{}, false
+}
+
+// Occurrences returns the occurrences of the window overlapping [from, to)
+func (w *MaintenanceWindow) Occurrences(from, to time.Time) []Interval {
+	length := w.End.Sub(w.Start)
+	if w.Recurrence == RecurrenceNone {
+		if w.Start.Before(to) && w.End.After(from) {
+			return []Interval{{w.Start, w.End}}
+		}
+		return nil
+	}
+
+	start := w.Start.In(w.location())
+	if from.After(w.Start) {
+		start = w.occurrenceBefore(from)
+	}
+	days := int(w.period() / (24 * time.Hour))
+
+	var occurrences []Interval
+	for ; start.Before(to); start = start.AddDate(0, 0, days) {
+		if w.Until != nil && start.After(*w.Until) {
+			break
+		}
+		end := start.Add(length)
+		if w.Until != nil && end.After(*w.Until) {
+			end = *w.Until
+		}
+		if end.After(from) {
+			occurrences = append(occurrences, Interval{start, end})
+		}
+	}
+	return occurrences
+}
+
+// occurrenceBefore returns the start of the latest occurrence not after t.
+// AddDate in the window's location keeps the wall-clock time stable across
+// DST changes, so occurrences are up to an hour off a multiple of the period.
+func (w *MaintenanceWindow) occurrenceBefore(t time.Time) time.Time {
+	first := w.Start.In(w.location())
+	days := int(w.period() / (24 * time.Hour))
+	k := int(t.Sub(first) / w.period())
+	for !first.AddDate(0, 0, (k+1)*days).After(t) {
+		k++
+	}
+	for k > 0 && first.AddDate(0, 0, k*days).After(t) {
+		k--
+	}
+	return first.AddDate(0, 0, k*days)
+}
+
+// location returns the time zone recurrences follow. Times are stored with a
+// fixed offset, which would drift by an hour across DST.
+func (w *MaintenanceWindow) location() *time.Location {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (w *MaintenanceWindow) period() time.Duration {
+	if w.Recurrence == RecurrenceWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
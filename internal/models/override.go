@@ -0,0 +1,75 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// ServiceOverride is the user-defined policy layer for a service.
+// It is merged over the axon payload on every registration, so values set here
+// survive heartbeats. Nil fields are not overridden.
+type ServiceOverride struct {
+	ServiceID    string               `gorm:"primaryKey" json:"service_id"`
+	Name         *string              `json:"name,omitempty"`
+	Icon         *string              `json:"icon,omitempty"`
+	Group        *string              `json:"group,omitempty"`
+	Tags         []string             `gorm:"serializer:json" json:"tags,omitempty"`
+	MarkdownDocs *string              `json:"markdown_docs,omitempty"`
+	Monitors     map[string][]Monitor `gorm:"serializer:json" json:"monitors,omitempty"` // Key: component ID
+	DependsOn    []string             `gorm:"serializer:json" json:"depends_on,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Apply merges the override over the service and records which fields were
+// replaced in svc.Overridden.
+func (o *ServiceOverride) Apply(svc *Service) {
+	svc.Overridden = nil
+	if o == nil {
+		return
+	}
+
+	if o.Name != nil {
+		svc.Name = *o.Name
+		svc.Overridden = append(svc.Overridden, "name")
+	}
+	if o.Icon != nil {
+		svc.Icon = *o.Icon
+		svc.Overridden = append(svc.Overridden, "icon")
+	}
+	if o.Group != nil {
+		svc.Group = *o.Group
+		svc.Overridden = append(svc.Overridden, "group")
+	}
+	if o.Tags != nil {
+		svc.Tags = o.Tags
+		svc.Overridden = append(svc.Overridden, "tags")
+	}
+	if o.MarkdownDocs != nil {
+		svc.MarkdownDocs = *o.MarkdownDocs
+		svc.Overridden = append(svc.Overridden, "markdown_docs")
+	}
+	if o.DependsOn != nil {
+		svc.DependsOn = o.DependsOn
+		svc.Overridden = append(svc.Overridden, "depends_on")
+	}
+
+	// Monitors are overridden per component. Components the axon no longer
+	// reports are ignored but kept in the override.
+	compIDs := make([]string, 0, len(o.Monitors))
+	for compID := range o.Monitors {
+		compIDs = append(compIDs, compID)
+	}
+	sort.Strings(compIDs)
+
+	for _, compID := range compIDs {
+		comp, ok := svc.Components[compID]
+		if !ok {
+			continue
+		}
+		comp.Monitors = o.Monitors[compID]
+		svc.Components[compID] = comp
+		svc.Overridden = append(svc.Overridden, "components."+compID+".monitors")
+	}
+}
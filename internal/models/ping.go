@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ping kinds, by URL suffix
+const (
+	PingSuccess = "success" // /ping/{token}
+	PingStart   = "start"   // /ping/{token}/start
+	PingFail    = "fail"    // /ping/{token}/fail
+)
+
+// PingCheck is a healthchecks.io style check for jobs too simple to send a
+// discovery payload, e.g. a cron script ending in `curl /ping/<token>`.
+// Every ping registers its service with a TTL of period + grace, so a job
+// that stops running goes offline through the heartbeat deadline.
+type PingCheck struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	ServiceID   string `gorm:"uniqueIndex" json:"service_id"`
+	Name        string `json:"name"` // Service name, defaults to the service ID
+	Group       string `json:"group,omitempty"`
+	Description string `json:"description,omitempty"`
+	Token       string `gorm:"uniqueIndex" json:"token,omitempty"` // Generated, only returned on create and rotate
+	Period      int    `json:"period"`                             // Expected seconds between runs, default 86400
+	Grace       int    `json:"grace"`                              // Seconds a run may be late or take, default 3600
+	MaxLog      int    `json:"max_log"`                            // Request bodies kept in the log, default 20
+
+	// Last pings
+	LastPing   *time.Time `json:"last_ping,omitempty"`
+	LastStart  *time.Time `json:"last_start,omitempty"` // Set while a run is in progress
+	LastResult string     `json:"last_result,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Defaults fills in the optional fields
+func (c *PingCheck) Defaults() {
+	if c.Name == "" {
+		c.Name = c.ServiceID
+	}
+	if c.Period == 0 {
+		c.Period = 86400
+	}
+	if c.Grace == 0 {
+		c.Grace = 3600
+	}
+	if c.MaxLog == 0 {
+		c.MaxLog = 20
+	}
+}
+
+// Validate checks the user supplied fields. Call Defaults first.
+func (c *PingCheck) Validate() error {
+	if c.ServiceID == "" {
+		return fmt.Errorf("service_id is required")
+	}
+	if c.Period < 60 {
+		return fmt.Errorf("period must be at least 60 seconds")
+	}
+	if c.Grace < 10 {
+		return fmt.Errorf("grace must be at least 10 seconds")
+	}
+	if c.MaxLog < 1 || c.MaxLog > 1000 {
+		return fmt.Errorf("max_log must be between 1 and 1000")
+	}
+	return nil
+}
+
+// TTL is the heartbeat TTL after a finished run: the next one is due within
+// period + grace
+func (c *PingCheck) TTL() int {
+	return c.Period + c.Grace
+}
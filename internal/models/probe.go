@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Probe types
+const (
+	ProbeHTTP = "http" // GET a URL, optionally matching the status code and body
+	ProbeTCP  = "tcp"  // Open a TCP connection
+	ProbeDNS  = "dns"  // Resolve a hostname
+	ProbePing = "ping" // TCP ping: a refused connection still proves the host is up
+)
+
+// Probe is a synthetic check run by Core for devices that cannot run an axon.
+// Its results are written into the service as ordinary components: a
+// status_indicator "<name>" (up/down, with a monitor on "down") and a stat
+// "<name>_latency". A service that does not exist yet is created by the probe
+// and expires through its TTL if the probe stops reporting.
+type Probe struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	ServiceID string `gorm:"index" json:"service_id"`
+	Name      string `json:"name"`     // Component ID, unique per service. Defaults to the type
+	Type      string `json:"type"`     // http, tcp, dns, ping
+	Target    string `json:"target"`   // URL for http, host:port for tcp/ping, hostname for dns
+	Interval  int    `json:"interval"` // Seconds, default 60
+	Timeout   int    `json:"timeout"`  // Seconds, default 10
+	Severity  string `json:"severity"` // Of the "down" monitor, default error
+
+	// HTTP
+	Method        string `json:"method,omitempty"`        // Default GET
+	ExpectStatus  int    `json:"expect_status,omitempty"` // Default: any status below 400
+	ExpectBody    string `json:"expect_body,omitempty"`   // Regular expression
+	SkipTLSVerify bool   `json:"skip_tls_verify,omitempty"`
+
+	// DNS
+	Server string `json:"server,omitempty"` // host:port of the resolver, default system
+
+	Auto bool `json:"auto"` // Created from the service URL (SYNAPSE_PROBE_URLS)
+
+	// Last result
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastUp      bool       `json:"last_up"`
+	LastLatency float64    `json:"last_latency"` // Milliseconds
+	LastError   string     `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var probeNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Defaults fills in the optional fields
+func (p *Probe) Defaults() {
+	if p.Name == "" {
+		p.Name = p.Type
+	}
+	if p.Interval == 0 {
+		p.Interval = 60
+	}
+	if p.Timeout == 0 {
+		p.Timeout = 10
+	}
+	if p.Severity == "" {
+		p.Severity = "error"
+	}
+	if p.Type == ProbeHTTP && p.Method == "" {
+		p.Method = "GET"
+	}
+}
+
+// Validate checks that the probe can be run. Call Defaults first.
+func (p *Probe) Validate() error {
+	if p.ServiceID == "" {
+		return fmt.Errorf("service_id is required")
+	}
+	if !probeNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("name may only contain letters, digits, '_' and '-'")
+	}
+	if p.Target == "" {
+		return fmt.Errorf("target is required")
+	}
+
+	switch p.Type {
+	case ProbeHTTP:
+		u, err := url.Parse(p.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("http target must be an http(s) URL")
+		}
+		if p.ExpectBody != "" {
+			if _, err := regexp.Compile(p.ExpectBody); err != nil {
+				return fmt.Errorf("invalid expect_body: %w", err)
+			}
+		}
+	case ProbeTCP, ProbePing:
+		if _, _, err := net.SplitHostPort(p.Target); err != nil {
+			return fmt.Errorf("%s target must be host:port", p.Type)
+		}
+	case ProbeDNS:
+		if p.Server != "" {
+			if _, _, err := net.SplitHostPort(p.Server); err != nil {
+				return fmt.Errorf("dns server must be host:port")
+			}
+		}
+	default:
+		return fmt.Errorf("unknown probe type '%s' (use http, tcp, dns or ping)", p.Type)
+	}
+
+	if p.Interval < 5 {
+		return fmt.Errorf("interval must be at least 5 seconds")
+	}
+	if p.Timeout <= 0 || p.Timeout >= p.Interval {
+		return fmt.Errorf("timeout must be positive and shorter than the interval")
+	}
+	switch p.Severity {
+	case "info", "warning", "error", "critical":
+	default:
+		return fmt.Errorf("unknown severity '%s'", p.Severity)
+	}
+	return nil
+}
+
+// TTL is the heartbeat TTL a probe-owned service gets: two missed runs
+func (p *Probe) TTL() int {
+	return 2*p.Interval + p.Timeout
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ScrapeTarget is a Prometheus /metrics endpoint scraped by Core. Every
+// scrape registers the service through the discovery path like an axon does,
+// with one stat or gauge component per mapped selector. The service expires
+// through its TTL when the endpoint stops answering.
+type ScrapeTarget struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	ServiceID   string         `gorm:"uniqueIndex" json:"service_id"`
+	Name        string         `json:"name"` // Service name, defaults to the service ID
+	Group       string         `json:"group,omitempty"`
+	Description string         `json:"description,omitempty"`
+	URL         string         `json:"url"`
+	Interval    int            `json:"interval"` // Seconds, default 60
+	Timeout     int            `json:"timeout"`  // Seconds, default 10
+	Metrics     []ScrapeMetric `gorm:"serializer:json" json:"metrics"`
+
+	// Last result
+	LastScrape *time.Time `json:"last_scrape,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ScrapeMetric maps the series picked by a selector to a component. Several
+// matching series are combined with Aggregate.
+type ScrapeMetric struct {
+	Selector  string    `json:"selector"`        // e.g. http_requests_total{code=~"5.."}
+	Component string    `json:"component"`       // Component ID
+	Type      string    `json:"type,omitempty"`  // stat (default) or gauge
+	Label     string    `json:"label,omitempty"` // Defaults to the component ID
+	Unit      string    `json:"unit,omitempty"`
+	Min       float64   `json:"min,omitempty"` // Gauge range
+	Max       float64   `json:"max,omitempty"`
+	Aggregate string    `json:"aggregate,omitempty"` // sum (default), avg, min or max
+	Monitors  []Monitor `json:"monitors,omitempty"`
+}
+
+// Defaults fills in the optional fields
+func (t *ScrapeTarget) Defaults() {
+	if t.Name == "" {
+		t.Name = t.ServiceID
+	}
+	if t.Interval == 0 {
+		t.Interval = 60
+	}
+	if t.Timeout == 0 {
+		t.Timeout = 10
+	}
+	for i := range t.Metrics {
+		if t.Metrics[i].Type == "" {
+			t.Metrics[i].Type = "stat"
+		}
+		if t.Metrics[i].Label == "" {
+			t.Metrics[i].Label = t.Metrics[i].Component
+		}
+		if t.Metrics[i].Aggregate == "" {
+			t.Metrics[i].Aggregate = AggregateSum
+		}
+	}
+}
+
+// Validate checks the target itself. Selectors and components are checked
+// by the scrape package and the discovery validation. Call Defaults first.
+func (t *ScrapeTarget) Validate() error {
+	if t.ServiceID == "" {
+		return fmt.Errorf("service_id is required")
+	}
+	u, err := url.Parse(t.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL")
+	}
+	if t.Interval < 5 {
+		return fmt.Errorf("interval must be at least 5 seconds")
+	}
+	if t.Timeout <= 0 || t.Timeout >= t.Interval {
+		return fmt.Errorf("timeout must be positive and shorter than the interval")
+	}
+	if len(t.Metrics) == 0 {
+		return fmt.Errorf("at least one metric is required")
+	}
+
+	seen := make(map[string]bool, len(t.Metrics))
+	for i, metric := range t.Metrics {
+		if metric.Component == "" {
+			return fmt.Errorf("metrics[%d]: component is required", i)
+		}
+		if seen[metric.Component] {
+			return fmt.Errorf("metrics[%d]: component '%s' is mapped twice", i, metric.Component)
+		}
+		seen[metric.Component] = true
+		if metric.Type != "stat" && metric.Type != "gauge" {
+			return fmt.Errorf("metrics[%d]: unknown type '%s' (use stat or gauge)", i, metric.Type)
+		}
+		if metric.Type == "gauge" {
+			max := metric.Max
+			if max == 0 {
+				max = DefaultGaugeMax
+			}
+			if max <= metric.Min {
+				return fmt.Errorf("metrics[%d]: gauge max must be greater than min (min=%v, max=%v)", i, metric.Min, max)
+			}
+		}
+		switch metric.Aggregate {
+		case AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		default:
+			return fmt.Errorf("metrics[%d]: unknown aggregate '%s' (use sum, avg, min or max)", i, metric.Aggregate)
+		}
+	}
+	return nil
+}
+
+// TTL is the heartbeat TTL of the scraped service: two missed scrapes
+func (t *ScrapeTarget) TTL() int {
+	return 2*t.Interval + t.Timeout
+}
@@ -15,18 +15,47 @@ type Service struct {
 	URL   string   `json:"url"`
 
 	// State
-	Status  string `gorm:"index" json:"status"` // online, warning, error, offline
-	Message string `json:"message"`
-	TTL     int    `json:"ttl"` // Seconds
+	Status         string          `gorm:"index" json:"status"` // Effective: online, warning, error, offline, maintenance, impacted
+	ReportedStatus string          `json:"reported_status"`     // As sent by the axon
+	Firing         []FiringMonitor `gorm:"serializer:json" json:"firing,omitempty"`
+	Message        string          `json:"message"`
+	TTL            int             `json:"ttl"` // Seconds
 
 	// Content
 	Description  string `json:"description"`
 	MarkdownDocs string `json:"markdown_docs"`
 
 	// Layout & Components (Protocol v2)
-	APIVersion string                  `json:"api_version"`
-	Layout     LayoutSchema            `gorm:"serializer:json" json:"layout"`
-	Components map[string]Component    `gorm:"serializer:json" json:"components"`
+	APIVersion string               `json:"api_version"`
+	Layout     LayoutSchema         `gorm:"serializer:json" json:"layout"`
+	Components map[string]Component `gorm:"serializer:json" json:"components"`
+
+	// Replicas
+	Aggregation *Aggregation      `gorm:"serializer:json" json:"aggregation,omitempty"`
+	Instances   []ServiceInstance `gorm:"-" json:"instances,omitempty"` // Detail view only
+
+	// Topology
+	DependsOn  []string `gorm:"serializer:json" json:"depends_on,omitempty"`  // Upstream service IDs
+	ImpactedBy []string `gorm:"serializer:json" json:"impacted_by,omitempty"` // Root causes while "impacted"
+
+	// Jobs
+	Schedule *JobSchedule `gorm:"serializer:json" json:"schedule,omitempty"`
+	Job      *JobState    `gorm:"serializer:json" json:"job,omitempty"` // Derived from job_runs, not reported
+
+	// Policy
+	Overridden []string   `gorm:"serializer:json" json:"overridden,omitempty"` // Fields replaced by a ServiceOverride
+	Archived   bool       `gorm:"index" json:"archived"`                       // Hidden from the list, alerts suppressed
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	Virtual    bool       `gorm:"index" json:"virtual,omitempty"` // Defined in Core, see VirtualService
+	Source     string     `json:"source,omitempty"`               // Created by Core (e.g. "probe") rather than an axon
+	SourceID   string     `json:"source_id,omitempty"`            // The webhook that created it, for source "webhook"
+
+	// Validation warnings of the last accepted payload
+	Issues []ValidationIssue `gorm:"serializer:json" json:"issues,omitempty"`
+
+	// Computed at read time
+	Maintenance *MaintenanceState `gorm:"-" json:"maintenance,omitempty"`
+	Uptime      *UptimeSummary    `gorm:"-" json:"uptime,omitempty"` // List only
 
 	// Metadata
 	LastSeen  time.Time `gorm:"index" json:"last_seen"`
@@ -51,7 +80,7 @@ type LayoutSection struct {
 // Formerly "Widget", now stored in a map. Properties are flat for simplicity.
 type Component struct {
 	ID    string `json:"id"`
-	Type  string `json:"type"` // stat, status_indicator, gauge, log_stream, action_group, link
+	Type  string `json:"type"` // stat, status_indicator, gauge, log_stream, action_group, link, job_history
 	Label string `json:"label"`
 	Value any    `json:"value"`
 	Unit  string `json:"unit"`
@@ -73,6 +102,30 @@ type Component struct {
 	Confirm    bool                   `json:"confirm,omitempty"`
 
 	Monitors []Monitor `json:"monitors"`
+
+	// Written by Core rather than the axon, e.g. "probe:3". Kept when the
+	// axon registers again.
+	Source string `json:"source,omitempty"`
+}
+
+// DefaultGaugeMax is the gauge maximum used when a component sets none
+const DefaultGaugeMax = 100
+
+// GaugeMax returns the maximum of a gauge, DefaultGaugeMax when unset
+func (c Component) GaugeMax() float64 {
+	if c.Max == 0 {
+		return DefaultGaugeMax
+	}
+	return c.Max
+}
+
+// ValidationIssue is a finding of the payload validation. Path points at the
+// offending field in the source document.
+type ValidationIssue struct {
+	Path     string `json:"path"`
+	Severity string `json:"severity"` // error, warning
+	Code     string `json:"code"`
+	Message  string `json:"message"`
 }
 
 // StatusState defines the visual style for a status_indicator state
@@ -102,5 +155,16 @@ type Monitor struct {
 type ServicePayload struct {
 	APIVersion string `json:"api_version"`
 	AuthToken  string `json:"auth_token"`
+	Instance   string `json:"instance,omitempty"` // Replica name, empty for single-instance services
 	Service           // Embed Service fields
 }
+
+// StatePayload matches the MQTT state JSON. It carries only the component
+// values that changed since the last discovery payload.
+type StatePayload struct {
+	AuthToken  string         `json:"auth_token"`
+	Instance   string         `json:"instance,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Components map[string]any `json:"components"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// ServiceSnapshot is a versioned copy of a registration payload.
+// A new version is stored whenever the definition changes (runtime values,
+// status and the auth token do not count).
+type ServiceSnapshot struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	ServiceID string    `gorm:"uniqueIndex:idx_snapshot_version,priority:1" json:"service_id"`
+	Version   int       `gorm:"uniqueIndex:idx_snapshot_version,priority:2" json:"version"`
+	Hash      string    `json:"hash"`
+	Payload   string    `json:"payload"` // Raw JSON, auth_token removed
+	CreatedAt time.Time `json:"created_at"`
+}
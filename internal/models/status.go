@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// FiringMonitor is a monitor whose condition currently holds
+type FiringMonitor struct {
+	ComponentID string    `json:"component_id"`
+	Monitor     int       `json:"monitor"` // Index into the component's monitors
+	Severity    string    `json:"severity"`
+	Message     string    `json:"message"`
+	Since       time.Time `json:"since"`
+}
+
+// statusRank orders the statuses a monitor can raise a service to
+var statusRank = map[string]int{
+	"online":  0,
+	"warning": 1,
+	"error":   2,
+}
+
+// SeverityStatus maps a monitor severity to the service status it implies.
+// Informational monitors don't affect the status.
+func SeverityStatus(severity string) string {
+	switch severity {
+	case "warning":
+		return "warning"
+	case "error", "critical":
+		return "error"
+	default:
+		return "online"
+	}
+}
+
+// EffectiveStatus combines the axon-reported status with the firing monitors:
+// the worse of the two wins. An axon reporting offline or maintenance is
+// taken at its word.
+func EffectiveStatus(reported string, firing []FiringMonitor) string {
+	if reported == "" {
+		reported = "online"
+	}
+	if _, ok := statusRank[reported]; !ok {
+		return reported
+	}
+
+	status := reported
+	for _, f := range firing {
+		if s := SeverityStatus(f.Severity); statusRank[s] > statusRank[status] {
+			status = s
+		}
+	}
+	return status
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// AxonToken is a per-axon credential. Only the SHA-256 hash of the secret is
+// stored; the plain token is returned once, when it is created or rotated.
+// Pattern is a path.Match glob over service IDs (e.g. "backup-*").
+type AxonToken struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `json:"name"`
+	Pattern    string     `json:"pattern"`
+	Hash       string     `gorm:"uniqueIndex" json:"-"`
+	Prefix     string     `json:"prefix"` // First characters of the secret, to tell tokens apart
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	Token string `gorm:"-" json:"token,omitempty"` // Plain secret, only set on creation
+}
+
+// Validate checks the user supplied fields of a token
+func (t *AxonToken) Validate() error {
+	if t.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if _, err := path.Match(t.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern '%s': %w", t.Pattern, err)
+	}
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
+
+// Allows reports whether the token may write the given service ID
+func (t *AxonToken) Allows(serviceID string) bool {
+	ok, _ := path.Match(t.Pattern, serviceID)
+	return ok
+}
+
+// Expired reports whether the token is past its expiry at time now
+func (t *AxonToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
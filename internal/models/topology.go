@@ -0,0 +1,24 @@
+package models
+
+// Topology is the service dependency graph
+type Topology struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+// TopologyNode is a service in the dependency graph. Dependencies on IDs that
+// are not registered show up as nodes with Missing set.
+type TopologyNode struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Group      string   `json:"group,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	ImpactedBy []string `json:"impacted_by,omitempty"`
+	Missing    bool     `json:"missing,omitempty"`
+}
+
+// TopologyEdge points from a service to a service it depends on
+type TopologyEdge struct {
+	From string `json:"from"` // Dependent
+	To   string `json:"to"`   // Upstream
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Uptime is the availability report of a service or group over a window.
+// Durations are in seconds. Time spent offline or impacted (or in
+// maintenance, unless excluded) counts as downtime; degraded states
+// (warning/error) count as available.
+type Uptime struct {
+	ServiceID string    `json:"service_id,omitempty"`
+	Group     string    `json:"group,omitempty"`
+	Window    string    `json:"window"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+
+	UptimePercent       float64 `json:"uptime_percent"`
+	Monitored           float64 `json:"monitored_seconds"` // Time taken into account, after exclusions
+	Downtime            float64 `json:"downtime_seconds"`
+	Outages             int     `json:"outages"`
+	MTTR                float64 `json:"mttr_seconds"` // Mean duration of the resolved outages
+	LongestOutage       float64 `json:"longest_outage_seconds"`
+	ExcludesMaintenance bool    `json:"excludes_maintenance"`
+
+	Services []Uptime `json:"services,omitempty"` // Per-service reports of a group
+}
+
+// UptimeSummary is the short form attached to the service list
+type UptimeSummary struct {
+	Window  string  `json:"window"`
+	Percent float64 `json:"percent"`
+}
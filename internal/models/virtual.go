@@ -0,0 +1,67 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// VirtualService is a service defined in Core instead of by an axon. Its
+// components are expr expressions over other services, re-evaluated whenever
+// one of its inputs changes. It is listed like any other service.
+type VirtualService struct {
+	ID          string                      `gorm:"primaryKey" json:"id"`
+	Name        string                      `json:"name"`
+	Group       string                      `json:"group"`
+	Tags        []string                    `gorm:"serializer:json" json:"tags"`
+	Icon        string                      `json:"icon"`
+	Description string                      `json:"description"`
+	Layout      LayoutSchema                `gorm:"serializer:json" json:"layout"`
+	Components  map[string]VirtualComponent `gorm:"serializer:json" json:"components"`
+	Inputs      []string                    `gorm:"serializer:json" json:"inputs"` // Referenced service IDs, derived from the expressions
+	CreatedAt   time.Time                   `json:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at"`
+}
+
+// VirtualComponent is a component whose value is computed by Expr, e.g.
+// services["nas"].components["disk"].value < 90
+type VirtualComponent struct {
+	Component
+	Expr string `json:"expr"`
+}
+
+// Service returns the service definition of v, without values.
+// Without a layout, all components go into a single section.
+func (v *VirtualService) Service() *Service {
+	svc := &Service{
+		ID:          v.ID,
+		Name:        v.Name,
+		Group:       v.Group,
+		Tags:        v.Tags,
+		Icon:        v.Icon,
+		Description: v.Description,
+		Layout:      v.Layout,
+		Components:  make(map[string]Component, len(v.Components)),
+		DependsOn:   v.Inputs,
+		Virtual:     true,
+	}
+	if svc.Name == "" {
+		svc.Name = v.ID
+	}
+
+	ids := make([]string, 0, len(v.Components))
+	for id, vc := range v.Components {
+		comp := vc.Component
+		comp.ID = id
+		comp.Value = nil
+		svc.Components[id] = comp
+		ids = append(ids, id)
+	}
+	if len(svc.Layout.Root) == 0 {
+		sort.Strings(ids)
+		svc.Layout = LayoutSchema{
+			Type: "sections",
+			Root: []LayoutSection{{Type: "section", Title: svc.Name, Children: ids}},
+		}
+	}
+	return svc
+}
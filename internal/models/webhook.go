@@ -0,0 +1,71 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Webhook is an inbound endpoint for tools that POST their own JSON (Gitea,
+// Proxmox backup hooks, Watchtower, UPS daemons). Its expressions extract a
+// service from the request, which is then registered like a discovery
+// payload. Expressions see the decoded body, the headers and the query.
+type Webhook struct {
+	ID         string                      `gorm:"primaryKey" json:"id"` // {hook_id} of the URL
+	Secret     string                      `json:"secret,omitempty"`     // Only returned when created
+	ServiceID  string                      `json:"service_id"`           // Expression, e.g. "gitea-" + body.repository.name
+	Name       string                      `json:"name,omitempty"`       // Expression, defaults to the service ID
+	Status     string                      `json:"status,omitempty"`     // Expression yielding a status, default online
+	Message    string                      `json:"message,omitempty"`    // Expression
+	Group      string                      `json:"group,omitempty"`
+	Tags       []string                    `gorm:"serializer:json" json:"tags,omitempty"`
+	Icon       string                      `json:"icon,omitempty"`
+	TTL        int                         `json:"ttl,omitempty"` // Seconds the service stays online without a new request
+	Layout     LayoutSchema                `gorm:"serializer:json" json:"layout"`
+	Components map[string]WebhookComponent `gorm:"serializer:json" json:"components"`
+
+	// Last request
+	LastReceived *time.Time `json:"last_received,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookComponent is a component whose value is extracted by Expr, e.g.
+// body.battery.charge
+type WebhookComponent struct {
+	Component
+	Expr string `json:"expr"`
+}
+
+// Service returns the service definition of w for the given service ID,
+// without values. Without a layout, all components go into a single section.
+func (w *Webhook) Service(id string) *Service {
+	svc := &Service{
+		ID:         id,
+		Name:       id,
+		Group:      w.Group,
+		Tags:       w.Tags,
+		Icon:       w.Icon,
+		TTL:        w.TTL,
+		Layout:     w.Layout,
+		Components: make(map[string]Component, len(w.Components)),
+	}
+
+	ids := make([]string, 0, len(w.Components))
+	for compID, wc := range w.Components {
+		comp := wc.Component
+		comp.ID = compID
+		comp.Value = nil
+		svc.Components[compID] = comp
+		ids = append(ids, compID)
+	}
+	if len(svc.Layout.Root) == 0 && len(ids) > 0 {
+		sort.Strings(ids)
+		svc.Layout = LayoutSchema{
+			Type: "sections",
+			Root: []LayoutSection{{Type: "section", Title: "Webhook", Children: ids}},
+		}
+	}
+	return svc
+}
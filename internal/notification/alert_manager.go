@@ -2,19 +2,43 @@ package notification
 
 import (
 	"fmt"
+	"log"
 	"sync"
 	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
 )
 
 type AlertState struct {
+	ServiceID     string // Kept apart from the key, service IDs may contain ':'
 	LastStatus    string
 	LastAlertTime time.Time
 }
 
+// Alert kinds
+const (
+	KindMonitor   = ""          // A component monitor
+	KindHeartbeat = "heartbeat" // A missed heartbeat (TTL expiry)
+)
+
+// Alert describes a monitor whose state is tracked by the AlertManager
+type Alert struct {
+	Key         string // Unique state key, "serviceID:componentID:mN", "serviceID:heartbeat" or "serviceID:job:state"
+	Kind        string
+	ServiceID   string
+	ServiceName string
+	ComponentID string
+	Severity    string
+	Message     string
+	Suppressed  string   // Reason the notification is withheld (e.g. maintenance), empty to send
+	Upstream    []string // Service IDs this service depends on
+}
+
 type AlertManager struct {
-	sender Sender
-	states map[string]*AlertState // Key: "serviceID:widgetIndex:monitorIndex"
-	mu     sync.Mutex
+	sender   Sender
+	states   map[string]*AlertState // Key: "serviceID:componentID:monitorIndex"
+	recorder func(evt models.Event)
+	mu       sync.Mutex
 }
 
 func NewAlertManager(sender Sender) *AlertManager {
@@ -24,36 +48,118 @@ func NewAlertManager(sender Sender) *AlertManager {
 	}
 }
 
+// SetEventRecorder sets the function used to persist fire/resolve events
+func (am *AlertManager) SetEventRecorder(fn func(evt models.Event)) {
+	am.recorder = fn
+}
+
 // CheckAndAlert evaluates if a notification should be sent based on state change
-func (am *AlertManager) CheckAndAlert(key string, isTriggered bool, severity, message, serviceName string) {
+func (am *AlertManager) CheckAndAlert(alert Alert, isTriggered bool) {
+	if am.transition(&alert, isTriggered) {
+		// Recorded outside the lock, the recorder writes to the database
+		am.record(alert, isTriggered)
+	}
+}
+
+// transition updates the state of an alert and sends the notification.
+// Reports whether the state changed.
+func (am *AlertManager) transition(alert *Alert, isTriggered bool) bool {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
-	state, exists := am.states[key]
+	state, exists := am.states[alert.Key]
 	if !exists {
-		state = &AlertState{LastStatus: "ok"}
-		am.states[key] = state
+		state = &AlertState{ServiceID: alert.ServiceID, LastStatus: "ok"}
+		am.states[alert.Key] = state
 	}
 
 	currentStatus := "ok"
 	if isTriggered {
-		currentStatus = severity
+		currentStatus = alert.Severity
 	}
 
 	// Logic: Alert only on state change
-	if state.LastStatus != currentStatus {
-		if isTriggered {
-			// Resolved -> Error/Warning
-			subject := fmt.Sprintf("%s: %s - %s", severity, serviceName, message)
-			body := fmt.Sprintf("Service: %s\nAlert: %s\nSeverity: %s\nTime: %s", serviceName, message, severity, time.Now().Format(time.RFC1123))
+	if state.LastStatus == currentStatus {
+		return false
+	}
+	if isTriggered {
+		// Resolved -> Error/Warning
+		// Report only the root cause when an upstream service is already alerting
+		if alert.Suppressed == "" {
+			if up := am.alertingUpstream(alert); up != "" {
+				alert.Suppressed = fmt.Sprintf("upstream '%s'", up)
+			}
+		}
+		subject := fmt.Sprintf("%s: %s - %s", alert.Severity, alert.ServiceName, alert.Message)
+		body := fmt.Sprintf("Service: %s\nAlert: %s\nSeverity: %s\nTime: %s", alert.ServiceName, alert.Message, alert.Severity, time.Now().Format(time.RFC1123))
+		if alert.Suppressed == "" {
 			go am.sender.Send(subject, body)
 		} else {
-			// Error/Warning -> Resolved
-			// Optional: Send "Resolved" email? For MVP, let's skip to reduce noise, or enable if requested.
-			// Let's print log.
-			fmt.Printf("Alert Resolved: %s - %s\n", serviceName, message)
+			log.Printf("Alert suppressed by %s: %s", alert.Suppressed, subject)
+		}
+	} else {
+		// Error/Warning -> Resolved
+		// Optional: Send "Resolved" email? For MVP, let's skip to reduce noise, or enable if requested.
+		// Let's print log.
+		fmt.Printf("Alert Resolved: %s - %s\n", alert.ServiceName, alert.Message)
+	}
+	state.LastStatus = currentStatus
+	state.LastAlertTime = time.Now()
+	return true
+}
+
+// alertingUpstream returns the first upstream service with an active
+// warning or error alert. Must be called with am.mu held.
+func (am *AlertManager) alertingUpstream(alert *Alert) string {
+	for _, up := range alert.Upstream {
+		if up == alert.ServiceID {
+			continue
+		}
+		for _, state := range am.states {
+			if state.ServiceID == up && state.LastStatus != "ok" && state.LastStatus != "info" {
+				return up
+			}
+		}
+	}
+	return ""
+}
+
+// Clear drops all tracked alert states of a service
+func (am *AlertManager) Clear(serviceID string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for key, state := range am.states {
+		if state.ServiceID == serviceID {
+			delete(am.states, key)
 		}
-		state.LastStatus = currentStatus
-		state.LastAlertTime = time.Now()
 	}
 }
+
+func (am *AlertManager) record(alert Alert, fired bool) {
+	if am.recorder == nil {
+		return
+	}
+
+	eventType := models.EventMonitorResolved
+	switch {
+	case alert.Kind == KindHeartbeat && fired:
+		eventType = models.EventHeartbeatMissed
+	case alert.Kind == KindHeartbeat:
+		eventType = models.EventHeartbeatResumed
+	case fired:
+		eventType = models.EventMonitorFired
+	}
+
+	message := alert.Message
+	if alert.Suppressed != "" && fired {
+		message = fmt.Sprintf("%s (notification suppressed by %s)", message, alert.Suppressed)
+	}
+	am.recorder(models.Event{
+		ServiceID:   alert.ServiceID,
+		Type:        eventType,
+		Severity:    alert.Severity,
+		ComponentID: alert.ComponentID,
+		Message:     message,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/wbw1537/synapse/internal/config"
+	"github.com/wbw1537/synapse/internal/metrics"
 )
 
 type Sender interface {
@@ -48,6 +49,7 @@ func (s *SMTPSender) Send(subject, body string) error {
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
+	metrics.EmailsSent.Inc()
 
 	log.Printf("Alert email sent to %s: %s", s.cfg.SMTPTo, subject)
 	return nil
@@ -0,0 +1,146 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// maxBody caps how much of an HTTP response is matched against expect_body
+const maxBody = 1 << 20
+
+// Probes share one client per TLS setting, so keep-alive connections are
+// reused across runs instead of leaking with a transport per run
+var (
+	httpClient     = newHTTPClient(false)
+	insecureClient = newHTTPClient(true)
+)
+
+func newHTTPClient(skipVerify bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: skipVerify}
+	return &http.Client{Transport: transport}
+}
+
+// Result is the outcome of a single probe run
+type Result struct {
+	Up      bool
+	Latency time.Duration
+	Error   string // Why the probe is down
+}
+
+// Run executes a probe once, bounded by its timeout
+func Run(ctx context.Context, p *models.Probe) Result {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch p.Type {
+	case models.ProbeHTTP:
+		err = runHTTP(ctx, p)
+	case models.ProbeTCP:
+		err = runTCP(ctx, p.Target, false)
+	case models.ProbePing:
+		err = runTCP(ctx, p.Target, true)
+	case models.ProbeDNS:
+		err = runDNS(ctx, p)
+	default:
+		err = fmt.Errorf("unknown probe type '%s'", p.Type)
+	}
+
+	result := Result{Up: err == nil, Latency: time.Since(start)}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func runHTTP(ctx context.Context, p *models.Probe) error {
+	req, err := http.NewRequestWithContext(ctx, p.Method, p.Target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Synapse-Probe/1.0")
+
+	client := httpClient
+	if p.SkipTLSVerify {
+		client = insecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Drained so the connection can be reused for the next run
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxBody))
+		resp.Body.Close()
+	}()
+
+	if p.ExpectStatus != 0 && resp.StatusCode != p.ExpectStatus {
+		return fmt.Errorf("status %d, expected %d", resp.StatusCode, p.ExpectStatus)
+	}
+	if p.ExpectStatus == 0 && resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	if p.ExpectBody != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+		if err != nil {
+			return fmt.Errorf("failed to read body: %w", err)
+		}
+		re, err := regexp.Compile(p.ExpectBody)
+		if err != nil {
+			return err
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("body does not match '%s'", p.ExpectBody)
+		}
+	}
+	return nil
+}
+
+// runTCP opens a connection to target. As a ping, a refused connection
+// counts as up: the host answered with a reset.
+func runTCP(ctx context.Context, target string, ping bool) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		if ping && errors.Is(err, syscall.ECONNREFUSED) {
+			return nil
+		}
+		return err
+	}
+	return conn.Close()
+}
+
+func runDNS(ctx context.Context, p *models.Probe) error {
+	resolver := net.DefaultResolver
+	if p.Server != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, p.Server)
+			},
+		}
+	}
+
+	addrs, err := resolver.LookupHost(ctx, p.Target)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("no addresses for '%s'", p.Target)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package recovery
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wbw1537/synapse/internal/axon"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// Supported kit languages
+const (
+	LangPython = "python"
+	LangGo     = "go"
+)
+
+// File is one file of a recovery kit
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Kit is a rehydration bundle for a lost axon: its axon.toml plus SDK
+// boilerplate with stub handlers for every action and an update call for
+// every component.
+type Kit struct {
+	ServiceID string
+	Files     []File
+}
+
+// action is a template view of an action_group button
+type action struct {
+	ID     string
+	Label  string
+	Func   string
+	Source string // Component ID of the action_group
+}
+
+// update is a template view of a component state update
+type update struct {
+	ID          string
+	Type        string
+	Label       string
+	Placeholder string
+}
+
+type view struct {
+	Service  *models.Service
+	Version  int
+	Interval int
+	Actions  []action
+	Updates  []update
+}
+
+// Build renders the recovery kit for a service snapshot
+func Build(svc *models.Service, version int, lang string) (*Kit, error) {
+	var tmpl *template.Template
+	var mainFile string
+	switch lang {
+	case LangPython, "":
+		tmpl, mainFile = pythonTemplate, "main.py"
+		lang = LangPython
+	case LangGo:
+		tmpl, mainFile = goTemplate, "main.go"
+	default:
+		return nil, fmt.Errorf("unsupported language '%s' (use python or go)", lang)
+	}
+
+	var toml bytes.Buffer
+	if err := axon.FromService(svc).Encode(&toml); err != nil {
+		return nil, fmt.Errorf("failed to render axon.toml: %w", err)
+	}
+
+	v := buildView(svc, version, lang)
+	var code bytes.Buffer
+	if err := tmpl.Execute(&code, v); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", mainFile, err)
+	}
+
+	return &Kit{
+		ServiceID: svc.ID,
+		Files: []File{
+			{Name: "axon.toml", Content: toml.Bytes()},
+			{Name: mainFile, Content: code.Bytes()},
+		},
+	}, nil
+}
+
+// WriteZip writes the kit as a zip archive rooted at a folder named after the service
+func (k *Kit) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for _, f := range k.Files {
+		fw, err := zw.Create(k.ServiceID + "/" + f.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.Content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func buildView(svc *models.Service, version int, lang string) view {
+	v := view{Service: svc, Version: version, Interval: svc.TTL / 2}
+	if v.Interval <= 0 {
+		v.Interval = 30
+	}
+
+	ids := make([]string, 0, len(svc.Components))
+	for id := range svc.Components {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	seen := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, id := range ids {
+		comp := svc.Components[id]
+		switch comp.Type {
+		case "action_group":
+			for _, item := range comp.Items {
+				if seen[item.ActionID] {
+					continue
+				}
+				seen[item.ActionID] = true
+				v.Actions = append(v.Actions, action{
+					ID:     item.ActionID,
+					Label:  item.Label,
+					Func:   uniqueName(handlerName(item.ActionID, lang), lang, names),
+					Source: id,
+				})
+			}
+		case "link":
+			// Static, nothing to update
+		default:
+			v.Updates = append(v.Updates, update{
+				ID:          id,
+				Type:        comp.Type,
+				Label:       comp.Label,
+				Placeholder: placeholder(comp, lang),
+			})
+		}
+	}
+	return v
+}
+
+// handlerName turns an action ID into a valid function name
+func handlerName(actionID, lang string) string {
+	parts := strings.FieldsFunc(actionID, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if lang == LangGo {
+		var b strings.Builder
+		b.WriteString("handle")
+		for _, p := range parts {
+			first, size := utf8.DecodeRuneInString(p)
+			b.WriteRune(unicode.ToUpper(first))
+			b.WriteString(p[size:])
+		}
+		return b.String()
+	}
+	return "on_" + strings.ToLower(strings.Join(parts, "_"))
+}
+
+// uniqueName appends a numeric suffix to name if it is already taken, e.g.
+// for the action IDs "restart-app" and "restart_app"
+func uniqueName(name, lang string, taken map[string]bool) string {
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		if lang == LangGo {
+			unique = fmt.Sprintf("%s%d", name, n)
+		} else {
+			unique = fmt.Sprintf("%s_%d", name, n)
+		}
+	}
+	taken[unique] = true
+	return unique
+}
+
+// templateFuncs quotes strings for the templates. strconv.Quote escapes are
+// valid in Python string literals too, and keep names on one line in comments.
+var templateFuncs = template.FuncMap{
+	"quote": strconv.Quote,
+}
+
+// placeholder returns a literal of the right type for a component update
+func placeholder(comp models.Component, lang string) string {
+	switch comp.Type {
+	case "gauge":
+		return "0"
+	case "log_stream":
+		return strconv.Quote("TODO: log line")
+	case "status_indicator":
+		keys := make([]string, 0, len(comp.Mapping))
+		for key := range comp.Mapping {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			return strconv.Quote(keys[0])
+		}
+	}
+
+	switch v := comp.Value.(type) {
+	case float64, int, int64:
+		return "0"
+	case bool:
+		if lang == LangPython {
+			return "False"
+		}
+		return "false"
+	case string:
+		return strconv.Quote(v)
+	}
+	return strconv.Quote("")
+}
+
+var pythonTemplate = template.Must(template.New("main.py").Funcs(templateFuncs).Parse(`"""
+Recovery kit for {{quote .Service.Name}} ({{quote .Service.ID}}).
+
+Generated by Synapse from registration snapshot v{{.Version}}.
+The layout, components and action contract are restored in axon.toml;
+fill in the TODOs below to bring the logic back.
+"""
+import time
+
+from synapse_axon import Axon
+
+axon = Axon("axon.toml")
+{{range .Actions}}
+
+@axon.on_action({{quote .ID}})
+def {{.Func}}():
+    """Handle {{quote .Label}} (from {{quote .Source}})."""
+    # TODO: restore the logic behind this action
+    print({{quote (printf "Action %s triggered" .ID)}})
+{{end}}
+
+def collect():
+    """Report the current state of every component."""
+{{- range .Updates}}
+    # TODO: {{.Type}}{{if .Label}} {{quote .Label}}{{end}}
+    axon.components[{{quote .ID}}].update({{.Placeholder}})
+{{- else}}
+    pass
+{{- end}}
+
+
+if __name__ == "__main__":
+    axon.start()
+    while True:
+        collect()
+        time.sleep({{.Interval}})
+`))
+
+var goTemplate = template.Must(template.New("main.go").Funcs(templateFuncs).Parse(`// Recovery kit for {{quote .Service.Name}} ({{quote .Service.ID}}).
+//
+// Generated by Synapse from registration snapshot v{{.Version}}.
+// The layout, components and action contract are restored in axon.toml;
+// fill in the TODOs below to bring the logic back.
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/wbw1537/synapse/sdk/go/axon"
+)
+
+func main() {
+	a, err := axon.NewAxon("axon.toml")
+	if err != nil {
+		log.Fatalf("Failed to load axon.toml: %v", err)
+	}
+{{range .Actions}}
+	a.OnAction({{quote .ID}}, {{.Func}})
+{{- end}}
+
+	if err := a.Start(); err != nil {
+		log.Fatalf("Failed to start axon: %v", err)
+	}
+
+	ticker := time.NewTicker({{.Interval}} * time.Second)
+	defer ticker.Stop()
+	for {
+		collect(a)
+		<-ticker.C
+	}
+}
+
+// collect reports the current state of every component
+func collect(a *axon.Axon) {
+{{- range .Updates}}
+	// TODO: {{.Type}}{{if .Label}} {{quote .Label}}{{end}}
+	a.Component({{quote .ID}}).Update({{.Placeholder}})
+{{- end}}
+}
+{{range .Actions}}
+// {{.Func}} handles {{quote .Label}} (from {{quote .Source}})
+func {{.Func}}() {
+	// TODO: restore the logic behind this action
+	log.Println({{quote (printf "Action %s triggered" .ID)}})
+}
+{{end}}`))
@@ -0,0 +1,279 @@
+package scrape
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxExposition caps the size of a scraped /metrics page
+const maxExposition = 10 << 20
+
+// Sample is a single series of an exposition
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Fetch scrapes a /metrics endpoint
+func Fetch(ctx context.Context, url string) ([]Sample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	req.Header.Set("User-Agent", "Synapse-Scrape/1.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return Parse(io.LimitReader(resp.Body, maxExposition))
+}
+
+// Parse reads the Prometheus text exposition format. Comments, HELP and TYPE
+// lines and timestamps are ignored.
+func Parse(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func parseLine(line string) (Sample, error) {
+	name, rest := splitName(line)
+	if name == "" {
+		return Sample{}, fmt.Errorf("missing metric name")
+	}
+	sample := Sample{Name: name, Labels: map[string]string{}}
+
+	if strings.HasPrefix(rest, "{") {
+		matchers, n, err := parseMatchers(rest, false)
+		if err != nil {
+			return Sample{}, err
+		}
+		for _, m := range matchers {
+			sample.Labels[m.Label] = m.Value
+		}
+		rest = rest[n:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Sample{}, fmt.Errorf("expected a value (and optional timestamp) after '%s'", name)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("invalid value '%s'", fields[0])
+	}
+	sample.Value = value
+	return sample, nil
+}
+
+// splitName cuts the leading metric name off a line or selector
+func splitName(s string) (string, string) {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			i++
+			continue
+		}
+		break
+	}
+	return s[:i], s[i:]
+}
+
+// Matcher is a label matcher of a selector: =, !=, =~ or !~
+type Matcher struct {
+	Label string
+	Op    string
+	Value string
+	re    *regexp.Regexp
+}
+
+func (m *Matcher) matches(value string) bool {
+	switch m.Op {
+	case "=":
+		return value == m.Value
+	case "!=":
+		return value != m.Value
+	case "=~":
+		return m.re.MatchString(value)
+	case "!~":
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// Selector picks series by metric name and label matchers, like a PromQL
+// instant vector selector: http_requests_total{code="200",method=~"GET|POST"}
+type Selector struct {
+	Name     string
+	Matchers []Matcher
+}
+
+// ParseSelector parses a selector. A missing label matches the empty string,
+// as in PromQL.
+func ParseSelector(s string) (*Selector, error) {
+	s = strings.TrimSpace(s)
+	name, rest := splitName(s)
+	if name == "" {
+		return nil, fmt.Errorf("selector '%s' must start with a metric name", s)
+	}
+	sel := &Selector{Name: name}
+	if rest == "" {
+		return sel, nil
+	}
+
+	matchers, n, err := parseMatchers(rest, true)
+	if err != nil {
+		return nil, fmt.Errorf("selector '%s': %w", s, err)
+	}
+	if strings.TrimSpace(rest[n:]) != "" {
+		return nil, fmt.Errorf("selector '%s': unexpected '%s'", s, strings.TrimSpace(rest[n:]))
+	}
+	for i := range matchers {
+		if matchers[i].Op == "=~" || matchers[i].Op == "!~" {
+			re, err := regexp.Compile("^(?:" + matchers[i].Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("selector '%s': %w", s, err)
+			}
+			matchers[i].re = re
+		}
+	}
+	sel.Matchers = matchers
+	return sel, nil
+}
+
+// Matches reports whether a sample is selected
+func (s *Selector) Matches(sample Sample) bool {
+	if sample.Name != s.Name {
+		return false
+	}
+	for i := range s.Matchers {
+		if !s.Matchers[i].matches(sample.Labels[s.Matchers[i].Label]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the finite values of the samples matching the selector
+func (s *Selector) Select(samples []Sample) []float64 {
+	var values []float64
+	for _, sample := range samples {
+		if s.Matches(sample) && !math.IsNaN(sample.Value) && !math.IsInf(sample.Value, 0) {
+			values = append(values, sample.Value)
+		}
+	}
+	return values
+}
+
+// parseMatchers parses a {label="value",...} block and returns the number of
+// bytes consumed. Outside selectors only "=" is allowed.
+func parseMatchers(s string, ops bool) ([]Matcher, int, error) {
+	var matchers []Matcher
+	i := 1 // Skip "{"
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return nil, 0, fmt.Errorf("unterminated label set")
+		}
+		if s[i] == '}' {
+			return matchers, i + 1, nil
+		}
+
+		start := i
+		for i < len(s) && (s[i] == '_' || (s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z') || (i > start && s[i] >= '0' && s[i] <= '9')) {
+			i++
+		}
+		m := Matcher{Label: s[start:i]}
+		if m.Label == "" {
+			return nil, 0, fmt.Errorf("expected a label name at '%s'", s[start:])
+		}
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+
+		switch {
+		case strings.HasPrefix(s[i:], "=~"), strings.HasPrefix(s[i:], "!~"), strings.HasPrefix(s[i:], "!="):
+			m.Op = s[i : i+2]
+		case strings.HasPrefix(s[i:], "="):
+			m.Op = "="
+		default:
+			return nil, 0, fmt.Errorf("expected an operator after label '%s'", m.Label)
+		}
+		if m.Op != "=" && !ops {
+			return nil, 0, fmt.Errorf("unexpected operator '%s'", m.Op)
+		}
+		i += len(m.Op)
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+
+		value, n, err := parseQuoted(s[i:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("label '%s': %w", m.Label, err)
+		}
+		m.Value = value
+		i += n
+		matchers = append(matchers, m)
+	}
+}
+
+// parseQuoted reads a double-quoted label value with \\, \" and \n escapes
+func parseQuoted(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, fmt.Errorf("expected a quoted value")
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, fmt.Errorf("unterminated value")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated value")
+}
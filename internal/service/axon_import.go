@@ -0,0 +1,59 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/wbw1537/synapse/internal/axon"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+)
+
+// ImportAxon registers a service from an axon.toml definition through the
+// regular Upsert path. Nothing is stored if validation finds errors.
+// Imported services start as offline until their axon reports.
+func (m *Manager) ImportAxon(token string, data []byte) (*models.Service, *validation.Report, error) {
+	cfg, report := axon.Load(data)
+	if cfg == nil || report.HasErrors() {
+		return nil, report, nil
+	}
+
+	svc := cfg.ToService()
+	svc.Status = "offline"
+	if existing, err := m.Get(svc.ID); err == nil {
+		// Keep a TTL expiry, monitor-derived states are recomputed on upsert
+		svc.Status = existing.Status
+		if svc.Status != "offline" && svc.Status != "maintenance" && existing.ReportedStatus != "" {
+			svc.Status = existing.ReportedStatus
+		}
+		// The TOML values are defaults, don't reset what the axon reported
+		for id, comp := range svc.Components {
+			if old, ok := existing.Components[id]; ok && old.Type == comp.Type {
+				comp.Value = old.Value
+				svc.Components[id] = comp
+			}
+		}
+	}
+
+	payload, err := json.Marshal(models.ServicePayload{
+		APIVersion: svc.APIVersion,
+		AuthToken:  token,
+		Service:    *svc,
+	})
+	if err != nil {
+		return nil, report, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	if err := m.Upsert("", payload); err != nil {
+		// Wire-level rejections (e.g. a bad token) are reported like TOML issues
+		var verr *validation.Error
+		if errors.As(err, &verr) {
+			report.Issues = append(report.Issues, verr.Report.Issues...)
+			return nil, report, nil
+		}
+		return nil, report, err
+	}
+
+	svc, err = m.Get(svc.ID)
+	return svc, report, err
+}
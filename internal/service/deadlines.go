@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/notification"
+)
+
+// StatusUpdate is published to synapse/v1/status/{id} on every change of the
+// effective status, so the UI does not have to poll for TTL expiries
+type StatusUpdate struct {
+	ServiceID      string    `json:"service_id"`
+	Status         string    `json:"status"`
+	PrevStatus     string    `json:"prev_status"`
+	ReportedStatus string    `json:"reported_status"`
+	Message        string    `json:"message"`
+	LastSeen       time.Time `json:"last_seen"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// deadlineKey identifies a heartbeat deadline. Instance is empty for the
+// deadline of a single-instance service.
+type deadlineKey struct {
+	serviceID string
+	instance  string
+}
+
+// StartDeadlines schedules the heartbeat deadline of every live service.
+// Deadlines are re-armed on each heartbeat; a service whose TTL is not
+// positive never expires. Multi-instance services expire per instance.
+func (m *Manager) StartDeadlines() error {
+	var services []models.Service
+	err := m.db.Conn.Select("id", "ttl", "last_seen").
+		Where("archived = ? AND status != 'offline'", false).
+		Find(&services).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	var instances []models.ServiceInstance
+	err = m.db.Conn.Select("service_id", "instance", "ttl", "last_seen").
+		Where("status != 'offline' AND service_id IN (?)", m.db.Conn.Model(&models.Service{}).Select("id").Where("archived = ?", false)).
+		Find(&instances).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	var replicated []string
+	if err := m.db.Conn.Model(&models.ServiceInstance{}).Distinct().Pluck("service_id", &replicated).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	scheduled := 0
+	for _, svc := range services {
+		if !slices.Contains(replicated, svc.ID) {
+			m.scheduleDeadline(deadlineKey{serviceID: svc.ID}, svc.LastSeen, svc.TTL)
+			scheduled++
+		}
+	}
+	for _, inst := range instances {
+		m.scheduleDeadline(deadlineKey{inst.ServiceID, inst.Instance}, inst.LastSeen, inst.TTL)
+	}
+	log.Printf("Scheduled heartbeat deadlines for %d services and %d instances", scheduled, len(instances))
+	return nil
+}
+
+// rearm schedules the deadlines of a service, or of each of its instances
+// if it is replicated. svc must come from Get so Instances is loaded.
+func (m *Manager) rearm(svc *models.Service) {
+	if len(svc.Instances) == 0 {
+		m.scheduleDeadline(deadlineKey{serviceID: svc.ID}, svc.LastSeen, svc.TTL)
+		return
+	}
+	for _, inst := range svc.Instances {
+		if inst.Status != "offline" {
+			m.scheduleDeadline(deadlineKey{svc.ID, inst.Instance}, inst.LastSeen, inst.TTL)
+		}
+	}
+}
+
+// heartbeat re-arms the deadline of a service that just reported and
+// resolves a pending heartbeat alert
+func (m *Manager) heartbeat(svc *models.Service) {
+	m.scheduleDeadline(deadlineKey{serviceID: svc.ID}, svc.LastSeen, svc.TTL)
+	m.alertManager.CheckAndAlert(heartbeatAlert(svc), false)
+}
+
+func (m *Manager) scheduleDeadline(key deadlineKey, lastSeen time.Time, ttl int) {
+	m.deadlineMu.Lock()
+	defer m.deadlineMu.Unlock()
+
+	if timer, ok := m.deadlines[key]; ok {
+		timer.Stop()
+		delete(m.deadlines, key)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	wait := time.Until(lastSeen.Add(time.Duration(ttl) * time.Second))
+	m.deadlines[key] = time.AfterFunc(wait, func() {
+		if key.instance != "" {
+			m.expireInstance(key.serviceID, key.instance)
+			return
+		}
+		m.expire(key.serviceID)
+	})
+}
+
+// cancelDeadline stops the deadlines of a service and all its instances
+func (m *Manager) cancelDeadline(id string) {
+	m.deadlineMu.Lock()
+	defer m.deadlineMu.Unlock()
+
+	for key, timer := range m.deadlines {
+		if key.serviceID == id {
+			timer.Stop()
+			delete(m.deadlines, key)
+		}
+	}
+}
+
+// expire handles a missed heartbeat. Silent services inside a maintenance
+// window are expected to be down: they are marked "maintenance" without an
+// alert and checked again when the window ends.
+func (m *Manager) expire(id string) {
+	var svc models.Service
+	result := m.db.Conn.Select(topologyColumns).Where("id = ?", id).Limit(1).Find(&svc)
+	if result.Error != nil {
+		log.Printf("Error checking deadline of %s: %v", id, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 || svc.Archived || svc.TTL <= 0 {
+		m.cancelDeadline(id)
+		return
+	}
+	key := deadlineKey{serviceID: id}
+
+	now := time.Now()
+	if deadline := svc.LastSeen.Add(time.Duration(svc.TTL) * time.Second); deadline.After(now) {
+		// A heartbeat arrived while the timer fired
+		m.scheduleDeadline(key, svc.LastSeen, svc.TTL)
+		return
+	}
+
+	prevStatus, prevRoots := svc.Status, svc.ImpactedBy
+	svc.Status = "offline"
+	message := "Heartbeat TTL expired"
+	mw := m.activeMaintenance(&svc)
+	if mw != nil {
+		svc.Status = "maintenance"
+		message = fmt.Sprintf("Heartbeat TTL expired during maintenance window '%s'", mw.Name)
+	}
+	m.applyImpact(&svc)
+
+	m.deadlineMu.Lock()
+	delete(m.deadlines, key)
+	if mw != nil {
+		m.deadlines[key] = time.AfterFunc(time.Until(mw.Ends), func() { m.expire(id) })
+	}
+	m.deadlineMu.Unlock()
+
+	if svc.Status == prevStatus && slices.Equal(svc.ImpactedBy, prevRoots) {
+		return
+	}
+
+	svc.UpdatedAt = now
+	result = m.db.Conn.Model(&svc).
+		Where("status = ? AND julianday(last_seen) < julianday(?)", prevStatus, svc.LastSeen.Add(time.Millisecond).UTC()).
+		Select("status", "impacted_by", "updated_at").
+		Updates(&svc)
+	if result.Error != nil {
+		log.Printf("Error marking %s %s: %v", id, svc.Status, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Heartbeat arrived in the meantime
+		return
+	}
+
+	m.recordStatusChange(&svc, prevStatus, message)
+	if svc.Status != "maintenance" {
+		m.alertManager.CheckAndAlert(heartbeatAlert(&svc), true)
+		log.Printf("Service %s (%s) missed its heartbeat, marked %s", svc.Name, id, svc.Status)
+	}
+}
+
+// heartbeatAlert describes the "heartbeat missed" alert of a service
+func heartbeatAlert(svc *models.Service) notification.Alert {
+	return notification.Alert{
+		Key:         svc.ID + ":heartbeat",
+		Kind:        notification.KindHeartbeat,
+		ServiceID:   svc.ID,
+		ServiceName: svc.Name,
+		Severity:    "error",
+		Message:     fmt.Sprintf("No heartbeat for %ds", svc.TTL),
+		Upstream:    svc.DependsOn,
+	}
+}
+
+// publishStatus announces an effective status change on the status topic
+func (m *Manager) publishStatus(svc *models.Service, prevStatus, message string) {
+	if m.publishFunc == nil {
+		return
+	}
+	topic := fmt.Sprintf("synapse/v1/status/%s", svc.ID)
+	update := StatusUpdate{
+		ServiceID:      svc.ID,
+		Status:         svc.Status,
+		PrevStatus:     prevStatus,
+		ReportedStatus: svc.ReportedStatus,
+		Message:        message,
+		LastSeen:       svc.LastSeen,
+		Timestamp:      time.Now(),
+	}
+	if err := m.publishFunc(topic, update); err != nil {
+		log.Printf("Failed to publish status of %s: %v", svc.ID, err)
+	}
+}
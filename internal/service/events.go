@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+const (
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+)
+
+// recordEvent persists an entry of the event log
+func (m *Manager) recordEvent(evt models.Event) {
+	if err := m.db.Conn.Create(&evt).Error; err != nil {
+		log.Printf("Failed to record %s event for %s: %v", evt.Type, evt.ServiceID, err)
+	}
+}
+
+// recordStatusChange writes a status_changed event, publishes the new status
+// and re-evaluates the dependents and virtual services if it differs
+func (m *Manager) recordStatusChange(svc *models.Service, prevStatus, message string) {
+	if prevStatus == svc.Status {
+		return
+	}
+	m.recordEvent(models.Event{
+		ServiceID:  svc.ID,
+		Type:       models.EventStatusChanged,
+		Status:     svc.Status,
+		PrevStatus: prevStatus,
+		Message:    message,
+	})
+	m.publishStatus(svc, prevStatus, message)
+	m.propagateImpact(svc.ID)
+	m.refreshVirtual(svc.ID)
+}
+
+// statusReason explains an effective status for the event log: the firing
+// monitor if one raised the status, the axon's message otherwise
+func statusReason(svc *models.Service) string {
+	if svc.Status != svc.ReportedStatus {
+		for _, f := range svc.Firing {
+			if models.SeverityStatus(f.Severity) == svc.Status && f.ComponentID == "" {
+				return fmt.Sprintf("Job %s: %s", svc.Job.State, f.Message)
+			}
+			if models.SeverityStatus(f.Severity) == svc.Status {
+				return fmt.Sprintf("Monitor on '%s' firing: %s", f.ComponentID, f.Message)
+			}
+		}
+	}
+	return svc.Message
+}
+
+// ListEvents returns the newest events matching the filter
+func (m *Manager) ListEvents(filter models.EventFilter) ([]models.Event, error) {
+	query := m.db.Conn.Model(&models.Event{})
+	if filter.ServiceID != "" {
+		query = query.Where("service_id = ?", filter.ServiceID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultEventLimit
+	}
+	if limit > maxEventLimit {
+		limit = maxEventLimit
+	}
+
+	var events []models.Event
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// historyResolution describes one storage tier of the history store
+type historyResolution struct {
+	name      string
+	bucket    time.Duration
+	retention func(m *Manager) time.Duration
+}
+
+// historyResolutions is ordered from finest to coarsest
+var historyResolutions = []historyResolution{
+	{models.ResolutionRaw, 0, func(m *Manager) time.Duration { return m.config.HistoryRawRetention }},
+	{models.ResolutionMinute, time.Minute, func(m *Manager) time.Duration { return m.config.HistoryMinuteRetention }},
+	{models.ResolutionHour, time.Hour, func(m *Manager) time.Duration { return m.config.HistoryHourRetention }},
+}
+
+// recordHistory stores the numeric stat/gauge values of a service update
+func (m *Manager) recordHistory(svc *models.Service) {
+	now := svc.LastSeen.UTC()
+
+	for compID, comp := range svc.Components {
+		if comp.Type != "stat" && comp.Type != "gauge" {
+			continue
+		}
+		value, ok := toFloat(comp.Value)
+		if !ok {
+			continue
+		}
+
+		err := m.db.Conn.Transaction(func(tx *gorm.DB) error {
+			sample := models.ComponentSample{
+				ServiceID:   svc.ID,
+				ComponentID: compID,
+				Timestamp:   now,
+				Value:       value,
+			}
+			if err := tx.Create(&sample).Error; err != nil {
+				return err
+			}
+
+			// Downsample on write: fold the value into its 1m and 1h buckets
+			for _, res := range historyResolutions[1:] {
+				rollup := models.ComponentRollup{
+					ServiceID:   svc.ID,
+					ComponentID: compID,
+					Resolution:  res.name,
+					Bucket:      now.Truncate(res.bucket),
+					Min:         value,
+					Max:         value,
+					Sum:         value,
+					Count:       1,
+				}
+				err := tx.Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "service_id"}, {Name: "component_id"}, {Name: "resolution"}, {Name: "bucket"}},
+					DoUpdates: clause.Assignments(map[string]interface{}{
+						"min_value":    gorm.Expr("min(component_rollups.min_value, excluded.min_value)"),
+						"max_value":    gorm.Expr("max(component_rollups.max_value, excluded.max_value)"),
+						"sum_value":    gorm.Expr("component_rollups.sum_value + excluded.sum_value"),
+						"sample_count": gorm.Expr("component_rollups.sample_count + excluded.sample_count"),
+					}),
+				}).Create(&rollup).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to record history (svc=%s, comp=%s): %v", svc.ID, compID, err)
+		}
+	}
+}
+
+// History returns min/max/avg buckets of size step for a numeric component.
+// The finest stored resolution that still covers `from` is used as source.
+func (m *Manager) History(serviceID, componentID string, from, to time.Time, step time.Duration) ([]models.HistoryPoint, error) {
+	from, to = from.UTC(), to.UTC()
+	if !to.After(from) {
+		return nil, fmt.Errorf("'to' must be after 'from'")
+	}
+	if step <= 0 {
+		step = to.Sub(from) / 100
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+
+	res := m.pickResolution(from, step)
+
+	type point struct {
+		t             time.Time
+		min, max, sum float64
+		count         int64
+	}
+	var points []point
+
+	if res.name == models.ResolutionRaw {
+		var samples []models.ComponentSample
+		err := m.db.Conn.
+			Where("service_id = ? AND component_id = ? AND timestamp >= ? AND timestamp < ?", serviceID, componentID, from, to).
+			Order("timestamp").
+			Find(&samples).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range samples {
+			points = append(points, point{s.Timestamp, s.Value, s.Value, s.Value, 1})
+		}
+	} else {
+		var rollups []models.ComponentRollup
+		err := m.db.Conn.
+			Where("service_id = ? AND component_id = ? AND resolution = ? AND bucket >= ? AND bucket < ?",
+				serviceID, componentID, res.name, from.Truncate(res.bucket), to).
+			Order("bucket").
+			Find(&rollups).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rollups {
+			points = append(points, point{r.Bucket, r.Min, r.Max, r.Sum, r.Count})
+		}
+	}
+
+	// Re-bucket into the requested step, aligned to step boundaries
+	buckets := make(map[int64]*models.HistoryPoint)
+	sums := make(map[int64]float64)
+	for _, p := range points {
+		t := p.t.Truncate(step)
+		if t.Before(from) {
+			t = from
+		}
+		key := t.UnixNano()
+		b, ok := buckets[key]
+		if !ok {
+			b = &models.HistoryPoint{Time: t, Min: p.min, Max: p.max}
+			buckets[key] = b
+		}
+		if p.min < b.Min {
+			b.Min = p.min
+		}
+		if p.max > b.Max {
+			b.Max = p.max
+		}
+		b.Count += p.count
+		sums[key] += p.sum
+	}
+
+	result := make([]models.HistoryPoint, 0, len(buckets))
+	for key, b := range buckets {
+		b.Avg = sums[key] / float64(b.Count)
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Time.Before(result[j].Time) })
+
+	return result, nil
+}
+
+func (m *Manager) pickResolution(from time.Time, step time.Duration) historyResolution {
+	// Allow a little slack so "the last 24h" still hits a 24h retention tier
+	now := time.Now().Add(-time.Minute)
+	for _, res := range historyResolutions {
+		if res.bucket > step {
+			continue
+		}
+		if from.Before(now.Add(-res.retention(m))) {
+			continue
+		}
+		return res
+	}
+	return historyResolutions[len(historyResolutions)-1]
+}
+
+// StartHistoryRetention periodically deletes history older than its retention
+func (m *Manager) StartHistoryRetention(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			m.pruneHistory()
+		}
+	}()
+}
+
+func (m *Manager) pruneHistory() {
+	now := time.Now().UTC()
+
+	for _, res := range historyResolutions {
+		cutoff := now.Add(-res.retention(m))
+
+		var result *gorm.DB
+		if res.name == models.ResolutionRaw {
+			result = m.db.Conn.Where("timestamp < ?", cutoff).Delete(&models.ComponentSample{})
+		} else {
+			result = m.db.Conn.Where("resolution = ? AND bucket < ?", res.name, cutoff).Delete(&models.ComponentRollup{})
+		}
+
+		if result.Error != nil {
+			log.Printf("Error pruning %s history: %v", res.name, result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Pruned %d %s history rows", result.RowsAffected, res.name)
+		}
+	}
+}
+
+// toFloat converts a JSON-decoded component value into a number
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Instances returns the replicas of a service, ordered by name
+func (m *Manager) Instances(serviceID string) ([]models.ServiceInstance, error) {
+	if _, err := m.Get(serviceID); err != nil {
+		return nil, err
+	}
+	return m.loadInstances(serviceID)
+}
+
+// DeleteInstance forgets a replica that was scaled down for good and
+// re-aggregates the service from the remaining instances
+func (m *Manager) DeleteInstance(serviceID, name string) error {
+	result := m.db.Conn.Delete(&models.ServiceInstance{}, "service_id = ? AND instance = ?", serviceID, name)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	m.cancelInstanceDeadline(serviceID, name)
+	m.reaggregate(serviceID, fmt.Sprintf("Instance '%s' removed", name))
+	log.Printf("Instance removed: %s/%s", serviceID, name)
+	return nil
+}
+
+func (m *Manager) loadInstances(serviceID string) ([]models.ServiceInstance, error) {
+	var instances []models.ServiceInstance
+	err := m.db.Conn.Where("service_id = ?", serviceID).Order("instance").Find(&instances).Error
+	return instances, err
+}
+
+// reportInstance stores the update of one replica and replaces the component
+// values and reported status of svc with the aggregate over all instances.
+// svc carries the component definitions; values are the replica's own.
+func (m *Manager) reportInstance(svc *models.Service, name, status, message string, ttl int, values map[string]any) (*models.ServiceInstance, error) {
+	var inst models.ServiceInstance
+	err := m.db.Conn.Where("service_id = ? AND instance = ?", svc.ID, name).Limit(1).Find(&inst).Error
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	inst.ServiceID = svc.ID
+	inst.Instance = name
+
+	// Log streams append per instance, like they do per service
+	stored := &models.Service{Components: make(map[string]models.Component, len(inst.Values))}
+	incoming := &models.Service{Components: make(map[string]models.Component, len(values))}
+	for compID, value := range inst.Values {
+		comp := svc.Components[compID]
+		comp.Value = value
+		stored.Components[compID] = comp
+	}
+	for compID, value := range values {
+		comp := svc.Components[compID]
+		comp.Value = value
+		incoming.Components[compID] = comp
+	}
+	m.mergeComponents(stored, incoming)
+
+	if inst.Values == nil {
+		inst.Values = make(map[string]any, len(values))
+	}
+	for compID, comp := range incoming.Components {
+		inst.Values[compID] = comp.Value
+	}
+
+	switch {
+	case status != "":
+		inst.Status = status
+	case inst.Status == "" || inst.Status == "offline":
+		inst.Status = "online"
+	}
+	if message != "" {
+		inst.Message = message
+	}
+	if ttl != 0 {
+		inst.TTL = ttl
+	} else if inst.CreatedAt.IsZero() {
+		// First heard of through a state update
+		inst.TTL = svc.TTL
+	}
+	inst.LastSeen = svc.LastSeen
+
+	err = m.db.Conn.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "service_id"}, {Name: "instance"}},
+		UpdateAll: true,
+	}).Create(&inst).Error
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+
+	instances, err := m.loadInstances(svc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	aggregateInstances(svc, instances)
+	return &inst, nil
+}
+
+// heartbeatInstance re-arms the deadline of a replica that just reported.
+// The service itself no longer expires on its own.
+func (m *Manager) heartbeatInstance(svc *models.Service, inst *models.ServiceInstance) {
+	m.deadlineMu.Lock()
+	if timer, ok := m.deadlines[deadlineKey{serviceID: svc.ID}]; ok {
+		timer.Stop()
+		delete(m.deadlines, deadlineKey{serviceID: svc.ID})
+	}
+	m.deadlineMu.Unlock()
+
+	m.scheduleDeadline(deadlineKey{svc.ID, inst.Instance}, inst.LastSeen, inst.TTL)
+	if svc.Status != "offline" {
+		m.alertManager.CheckAndAlert(heartbeatAlert(svc), false)
+	}
+}
+
+func (m *Manager) cancelInstanceDeadline(serviceID, name string) {
+	m.deadlineMu.Lock()
+	defer m.deadlineMu.Unlock()
+
+	key := deadlineKey{serviceID, name}
+	if timer, ok := m.deadlines[key]; ok {
+		timer.Stop()
+		delete(m.deadlines, key)
+	}
+}
+
+// expireInstance marks a silent replica offline and re-aggregates its service
+func (m *Manager) expireInstance(serviceID, name string) {
+	var inst models.ServiceInstance
+	result := m.db.Conn.Where("service_id = ? AND instance = ?", serviceID, name).Limit(1).Find(&inst)
+	if result.Error != nil {
+		log.Printf("Error checking deadline of %s/%s: %v", serviceID, name, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 || inst.TTL <= 0 || inst.Status == "offline" {
+		m.cancelInstanceDeadline(serviceID, name)
+		return
+	}
+
+	if deadline := inst.LastSeen.Add(time.Duration(inst.TTL) * time.Second); deadline.After(time.Now()) {
+		// A heartbeat arrived while the timer fired
+		m.scheduleDeadline(deadlineKey{serviceID, name}, inst.LastSeen, inst.TTL)
+		return
+	}
+	m.cancelInstanceDeadline(serviceID, name)
+
+	result = m.db.Conn.Model(&inst).
+		Where("julianday(last_seen) < julianday(?)", inst.LastSeen.Add(time.Millisecond).UTC()).
+		Update("status", "offline")
+	if result.Error != nil {
+		log.Printf("Error marking %s/%s offline: %v", serviceID, name, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Heartbeat arrived in the meantime
+		return
+	}
+
+	log.Printf("Instance %s/%s missed its heartbeat, marked offline", serviceID, name)
+	m.reaggregate(serviceID, fmt.Sprintf("Instance '%s' missed its heartbeat", name))
+}
+
+// reaggregate recomputes the status and values of a replicated service after
+// one of its instances expired or was removed
+func (m *Manager) reaggregate(serviceID, message string) {
+	svc, err := m.Get(serviceID)
+	if err != nil {
+		log.Printf("Error re-aggregating %s: %v", serviceID, err)
+		return
+	}
+	if svc.Archived || len(svc.Instances) == 0 {
+		// The last replica is gone: the service expires on its own again
+		m.rearm(svc)
+		return
+	}
+
+	prevStatus := svc.Status
+	aggregateInstances(svc, svc.Instances)
+	m.evaluateMonitors(svc, svc.Firing)
+	svc.Status = models.EffectiveStatus(svc.ReportedStatus, svc.Firing)
+	if svc.Status == "offline" && m.activeMaintenance(svc) != nil {
+		svc.Status = "maintenance"
+	}
+	m.applyImpact(svc)
+	svc.UpdatedAt = time.Now()
+
+	err = m.db.Conn.Model(svc).
+		Select("status", "reported_status", "firing", "impacted_by", "issues", "components", "updated_at").
+		Updates(svc).Error
+	if err != nil {
+		log.Printf("Error re-aggregating %s: %v", serviceID, err)
+		return
+	}
+
+	m.recordStatusChange(svc, prevStatus, message)
+	if svc.Status == "offline" && prevStatus != "offline" {
+		m.alertManager.CheckAndAlert(heartbeatAlert(svc), true)
+	}
+}
+
+// aggregateInstances sets the reported status and component values of svc
+// from its instances. Values come from the live instances only, unless all
+// of them are offline.
+func aggregateInstances(svc *models.Service, instances []models.ServiceInstance) {
+	var rules models.Aggregation
+	if svc.Aggregation != nil {
+		rules = *svc.Aggregation
+	}
+
+	statuses := make([]string, len(instances))
+	var live []models.ServiceInstance
+	for i, inst := range instances {
+		statuses[i] = inst.Status
+		if inst.Status != "offline" {
+			live = append(live, inst)
+		}
+	}
+	svc.ReportedStatus = models.AggregateStatus(rules.Status, statuses)
+
+	if len(live) == 0 {
+		live = instances
+	}
+	// Oldest first, so the last value seen wins for non-numeric components
+	live = append([]models.ServiceInstance(nil), live...)
+	sort.SliceStable(live, func(i, j int) bool { return live[i].LastSeen.Before(live[j].LastSeen) })
+
+	for compID, comp := range svc.Components {
+		var last any
+		var numbers []float64
+		reported := 0
+		for _, inst := range live {
+			value, ok := inst.Values[compID]
+			if !ok {
+				continue
+			}
+			reported++
+			last = value
+			if n, ok := toFloat(value); ok {
+				numbers = append(numbers, n)
+			}
+		}
+		if reported == 0 {
+			continue
+		}
+
+		rule := rules.Components[compID]
+		if rule != models.AggregateLast && len(numbers) == reported {
+			comp.Value = models.AggregateNumbers(rule, numbers)
+		} else {
+			comp.Value = last
+		}
+		svc.Components[compID] = comp
+	}
+}
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/cron"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/notification"
+	"github.com/wbw1537/synapse/internal/validation"
+)
+
+// maxRunOutput caps the stored output tail of a run
+const maxRunOutput = 4 << 10
+
+// maxMissedRuns caps the slots checked and recorded as missed at once, e.g.
+// after downtime
+const maxMissedRuns = 10
+
+// jobSeverity is the severity a job state fires with
+var jobSeverity = map[string]string{
+	models.JobFailed:   "error",
+	models.JobMissed:   "error",
+	models.JobOverlong: "warning",
+}
+
+// jobProblems lists the firing job states. The index is the Monitor of
+// their firing entry.
+var jobProblems = []string{models.JobFailed, models.JobMissed, models.JobOverlong}
+
+// jobAlert names the alert of a firing job state
+var jobAlert = map[string]string{
+	models.JobFailed:   "Job run failed",
+	models.JobMissed:   "Scheduled job run missed",
+	models.JobOverlong: "Job run exceeds max_duration",
+}
+
+// maxRunLimit caps the runs returned at once
+const maxRunLimit = 1000
+
+// Runs returns the newest runs of a job service
+func (m *Manager) Runs(serviceID string, limit int) ([]models.JobRun, error) {
+	if _, err := m.Get(serviceID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxRunLimit {
+		limit = maxRunLimit
+	}
+	return m.loadRuns(serviceID, limit)
+}
+
+func (m *Manager) loadRuns(serviceID string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	err := m.db.Conn.Where("service_id = ?", serviceID).
+		Order("COALESCE(started_at, scheduled_at) DESC, id DESC").
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// ReportRun records the start or end of a job run. Rejected payloads return
+// a *validation.Error.
+func (m *Manager) ReportRun(id string, payload []byte) (*models.JobRun, error) {
+	var p models.RunPayload
+	report := &validation.Report{}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		report.Errorf("", "invalid_json", "invalid json: %v", err)
+		return nil, m.reject(id, report)
+	}
+	if err := m.authorize(id, p.AuthToken); err != nil {
+		report.Errorf("auth_token", "unauthorized", "%v", err)
+		return nil, m.reject(id, report)
+	}
+	if p.Event == "" {
+		p.Event = "end"
+	}
+	if p.Event != "start" && p.Event != "end" {
+		report.Errorf("event", "invalid_event", "unknown event '%s' (use start or end)", p.Event)
+	}
+	if p.Duration != nil && *p.Duration < 0 {
+		report.Errorf("duration", "invalid_range", "duration must not be negative")
+	}
+
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+
+	existing, err := m.Get(id)
+	if err != nil {
+		report.Errorf("", "not_registered", "service '%s' is not registered", id)
+		return nil, m.reject(id, report)
+	}
+	if existing.Virtual {
+		report.Errorf("", "virtual_service", "service '%s' is a virtual service", id)
+	}
+	if report.HasErrors() {
+		return nil, m.reject(id, report)
+	}
+
+	now := time.Now()
+	run, err := m.recordRun(id, &p, now)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := *existing
+	svc.LastSeen = now
+	job := jobState(existing)
+	job.LastRun = run.StartedAt
+	switch run.Status {
+	case models.RunRunning:
+		job.State, job.Message = models.JobRunning, "Running"
+	case models.RunFailed:
+		job.State, job.Message = models.JobFailed, fmt.Sprintf("Last run failed with exit code %d", *run.ExitCode)
+	default:
+		job.State, job.Message = models.JobOK, "Last run succeeded"
+	}
+	svc.Job = job
+	if svc.Schedule != nil {
+		m.checkSchedule(&svc, now)
+	}
+	if err := m.saveJob(&svc, existing); err != nil {
+		return nil, err
+	}
+	m.heartbeat(&svc)
+	m.scheduleJob(&svc)
+	return run, nil
+}
+
+// recordRun creates or completes the job_runs row of a report
+func (m *Manager) recordRun(serviceID string, p *models.RunPayload, now time.Time) (*models.JobRun, error) {
+	if p.Event == "start" {
+		started := now
+		if p.StartedAt != nil {
+			started = *p.StartedAt
+		}
+		run := &models.JobRun{ServiceID: serviceID, RunID: p.RunID, Status: models.RunRunning, StartedAt: &started}
+		if err := m.db.Conn.Create(run).Error; err != nil {
+			return nil, fmt.Errorf("db error: %w", err)
+		}
+		return run, nil
+	}
+
+	// An end completes the matching running run, or the latest one
+	run := &models.JobRun{}
+	query := m.db.Conn.Where("service_id = ? AND status = ?", serviceID, models.RunRunning)
+	if p.RunID != "" {
+		query = query.Where("run_id = ?", p.RunID)
+	}
+	result := query.Order("started_at DESC").Limit(1).Find(run)
+	if result.Error != nil {
+		return nil, fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// A one-shot report of a run that already finished
+		started := now
+		switch {
+		case p.StartedAt != nil:
+			started = *p.StartedAt
+		case p.Duration != nil:
+			started = now.Add(-time.Duration(*p.Duration * float64(time.Second)))
+		}
+		run = &models.JobRun{ServiceID: serviceID, RunID: p.RunID, StartedAt: &started}
+	}
+
+	exitCode := 0
+	if p.ExitCode != nil {
+		exitCode = *p.ExitCode
+	}
+	duration := now.Sub(*run.StartedAt).Seconds()
+	if p.Duration != nil {
+		duration = *p.Duration
+	}
+	output := p.Output
+	if len(output) > maxRunOutput {
+		output = output[len(output)-maxRunOutput:]
+	}
+
+	run.EndedAt = &now
+	run.Duration = &duration
+	run.ExitCode = &exitCode
+	run.Output = output
+	run.Status = models.RunSuccess
+	if exitCode != 0 {
+		run.Status = models.RunFailed
+	}
+	if err := m.db.Conn.Save(run).Error; err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return run, nil
+}
+
+// StartJobs arms the schedule checks of all job services
+func (m *Manager) StartJobs() error {
+	var services []models.Service
+	err := m.db.Conn.Select("id").
+		Where("schedule IS NOT NULL AND schedule != 'null' AND archived = ?", false).
+		Find(&services).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	for _, svc := range services {
+		id := svc.ID
+		m.jobMu.Lock()
+		m.jobTimers[id] = time.AfterFunc(0, func() { m.checkJob(id) })
+		m.jobMu.Unlock()
+	}
+	log.Printf("Scheduled %d job checks", len(services))
+	return nil
+}
+
+// scheduleJob arms the next check of a job service: the end of the grace
+// period of its next scheduled run, or the max_duration of a running run,
+// whichever comes first
+func (m *Manager) scheduleJob(svc *models.Service) {
+	m.cancelJob(svc.ID)
+	if svc.Schedule == nil || svc.Job == nil || svc.Archived {
+		return
+	}
+
+	var at time.Time
+	if svc.Job.NextRun != nil {
+		at = svc.Job.NextRun.Add(time.Duration(svc.Schedule.GraceOrDefault()) * time.Second)
+	}
+	if svc.Job.State == models.JobRunning && svc.Schedule.MaxDuration > 0 && svc.Job.LastRun != nil {
+		limit := svc.Job.LastRun.Add(time.Duration(svc.Schedule.MaxDuration) * time.Second)
+		if at.IsZero() || limit.Before(at) {
+			at = limit
+		}
+	}
+	if at.IsZero() {
+		return
+	}
+
+	id := svc.ID
+	m.jobMu.Lock()
+	m.jobTimers[id] = time.AfterFunc(time.Until(at), func() { m.checkJob(id) })
+	m.jobMu.Unlock()
+}
+
+func (m *Manager) cancelJob(id string) {
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+
+	if timer, ok := m.jobTimers[id]; ok {
+		timer.Stop()
+		delete(m.jobTimers, id)
+	}
+}
+
+// checkJob looks for missed scheduled runs and overlong running runs
+func (m *Manager) checkJob(id string) {
+	m.runMu.Lock()
+	existing, err := m.Get(id)
+	if err != nil || existing.Schedule == nil || existing.Archived {
+		m.runMu.Unlock()
+		m.cancelJob(id)
+		return
+	}
+	svc := *existing
+	svc.Job = jobState(existing)
+	changed := m.checkSchedule(&svc, time.Now())
+	if changed {
+		if err := m.saveJob(&svc, existing); err != nil {
+			log.Printf("Failed to update job state of %s: %v", id, err)
+		}
+	}
+	m.runMu.Unlock()
+
+	m.scheduleJob(&svc)
+}
+
+// checkSchedule updates svc.Job for the current time and records missed runs.
+// It reports whether the job state changed.
+func (m *Manager) checkSchedule(svc *models.Service, now time.Time) bool {
+	job := svc.Job
+	prev := *job
+	sched, err := cron.Parse(svc.Schedule.Cron)
+	if err != nil {
+		log.Printf("Invalid schedule of %s: %v", svc.ID, err)
+		return false
+	}
+	loc := time.UTC
+	if svc.Schedule.Timezone != "" {
+		if l, err := time.LoadLocation(svc.Schedule.Timezone); err == nil {
+			loc = l
+		}
+	}
+	grace := time.Duration(svc.Schedule.GraceOrDefault()) * time.Second
+
+	// Overlong: the running run passed max_duration
+	if job.State == models.JobRunning && svc.Schedule.MaxDuration > 0 && job.LastRun != nil {
+		limit := time.Duration(svc.Schedule.MaxDuration) * time.Second
+		if now.Sub(*job.LastRun) >= limit {
+			job.State = models.JobOverlong
+			job.Message = fmt.Sprintf("Run started at %s exceeds %s", job.LastRun.UTC().Format(time.RFC3339), limit)
+			err := m.db.Conn.Model(&models.JobRun{}).
+				Where("service_id = ? AND status = ?", svc.ID, models.RunRunning).
+				Update("overlong", true).Error
+			if err != nil {
+				log.Printf("Failed to flag overlong run of %s: %v", svc.ID, err)
+			}
+		}
+	}
+
+	// Missed: a scheduled time passed its grace period without a run
+	if job.State == "" {
+		job.State = models.JobOK
+	}
+	if job.Checked == nil {
+		checked := now
+		job.Checked = &checked
+	}
+	// Only the latest maxMissedRuns slots whose grace period is over are
+	// checked, however long Core was down
+	due := now.Add(-grace)
+	var slots []time.Time
+	slot := sched.Next(missedStart(sched, job.Checked.In(loc), due))
+	for ; !slot.IsZero() && !slot.After(due); slot = sched.Next(slot) {
+		slots = append(slots, slot)
+		if len(slots) > maxMissedRuns {
+			slots = slots[1:]
+		}
+	}
+	var missed []time.Time
+	for _, at := range slots {
+		var count int64
+		err := m.db.Conn.Model(&models.JobRun{}).
+			Where("service_id = ? AND started_at BETWEEN ? AND ?", svc.ID, at.Add(-grace).UTC(), at.Add(grace).UTC()).
+			Count(&count).Error
+		if err != nil {
+			log.Printf("Failed to check runs of %s: %v", svc.ID, err)
+			return false
+		}
+		if count == 0 {
+			missed = append(missed, at)
+		}
+		checked := at
+		job.Checked = &checked
+	}
+	for _, at := range missed {
+		scheduled := at.UTC()
+		run := models.JobRun{ServiceID: svc.ID, Status: models.RunMissed, ScheduledAt: &scheduled}
+		if err := m.db.Conn.Create(&run).Error; err != nil {
+			log.Printf("Failed to record missed run of %s: %v", svc.ID, err)
+		}
+	}
+	if len(missed) > 0 && job.State != models.JobRunning && job.State != models.JobOverlong {
+		job.State = models.JobMissed
+		job.Message = fmt.Sprintf("Scheduled run at %s did not start", missed[len(missed)-1].Format(time.RFC3339))
+	}
+
+	if slot.IsZero() {
+		job.NextRun = nil
+	} else {
+		next := slot.UTC()
+		job.NextRun = &next
+	}
+	return len(missed) > 0 || prev.State != job.State || !timeEqual(prev.NextRun, job.NextRun) || !timeEqual(prev.Checked, job.Checked)
+}
+
+// saveJob stores a changed job state and the effective status it implies
+func (m *Manager) saveJob(svc, existing *models.Service) error {
+	m.fillJobHistory(svc)
+	m.evaluateMonitors(svc, existing.Firing)
+	svc.Status = m.baseStatus(svc, time.Now())
+	m.applyImpact(svc)
+
+	err := m.db.Conn.Model(svc).
+		Select("components", "job", "status", "firing", "impacted_by", "issues", "last_seen").
+		Updates(svc).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	m.recordStatusChange(svc, existing.Status, statusReason(svc))
+	if existing.Status == svc.Status {
+		m.refreshVirtual(svc.ID)
+	}
+	return nil
+}
+
+// missedStart returns where to look for missed slots up to due: checked, or
+// a later time with at least maxMissedRuns slots before due. The window grows
+// by doubling, so a long downtime costs a few walks instead of every slot.
+func missedStart(sched *cron.Schedule, checked, due time.Time) time.Time {
+	for span := time.Hour; ; span *= 2 {
+		start := due.Add(-span)
+		if !start.After(checked) {
+			return checked
+		}
+		n := 0
+		for slot := sched.Next(start); !slot.IsZero() && !slot.After(due) && n < maxMissedRuns; slot = sched.Next(slot) {
+			n++
+		}
+		if n >= maxMissedRuns {
+			return start
+		}
+	}
+}
+
+// fillJobHistory sets the value of the job_history components of a service
+// to its newest runs
+func (m *Manager) fillJobHistory(svc *models.Service) {
+	for compID, comp := range svc.Components {
+		if comp.Type != "job_history" {
+			continue
+		}
+		limit := comp.MaxItems
+		if limit <= 0 {
+			limit = 10
+		}
+		runs, err := m.loadRuns(svc.ID, limit)
+		if err != nil {
+			log.Printf("Failed to load runs of %s: %v", svc.ID, err)
+			continue
+		}
+		// The output stays in the runs endpoint
+		for i := range runs {
+			runs[i].Output = ""
+		}
+		comp.Value = runs
+		svc.Components[compID] = comp
+	}
+}
+
+// jobFiring returns the firing entry of a failed, missed or overlong job and
+// notifies on transitions. It is evaluated with the component monitors and
+// has no component ID.
+func (m *Manager) jobFiring(svc *models.Service, since map[string]time.Time, now time.Time, suppressed string) []models.FiringMonitor {
+	if svc.Job == nil {
+		return nil
+	}
+	var firing []models.FiringMonitor
+	for i, state := range jobProblems {
+		triggered := svc.Job.State == state
+		message := jobAlert[state]
+		if triggered {
+			start, ok := since[fmt.Sprintf(":m%d", i)]
+			if !ok {
+				start = now
+			}
+			firing = append(firing, models.FiringMonitor{
+				Monitor:  i,
+				Severity: jobSeverity[state],
+				Message:  svc.Job.Message,
+				Since:    start,
+			})
+			message = fmt.Sprintf("%s: %s", message, svc.Job.Message)
+		}
+		m.alertManager.CheckAndAlert(notification.Alert{
+			Key:         fmt.Sprintf("%s:job:%s", svc.ID, state),
+			ServiceID:   svc.ID,
+			ServiceName: svc.Name,
+			Severity:    jobSeverity[state],
+			Message:     message,
+			Suppressed:  suppressed,
+			Upstream:    svc.DependsOn,
+		}, triggered)
+	}
+	return firing
+}
+
+// jobState returns a copy of the job state of a service to update
+func jobState(svc *models.Service) *models.JobState {
+	if svc.Job == nil {
+		return &models.JobState{}
+	}
+	job := *svc.Job
+	return &job
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
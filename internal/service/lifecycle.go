@@ -0,0 +1,201 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+// Delete removes a service and everything Core stores about it, except the
+// event log, which is kept for post-mortems. An axon that keeps publishing
+// will simply register again.
+func (m *Manager) Delete(id string) error {
+	svc, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+
+	var probes []models.Probe
+	var targets []models.ScrapeTarget
+	err = m.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Service{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ServiceOverride{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ServiceSnapshot{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ServiceInstance{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.VirtualService{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("service_id = ?", id).Find(&probes).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Probe{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("service_id = ?", id).Find(&targets).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ScrapeTarget{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.PingCheck{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.JobRun{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.ComponentSample{}, "service_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ComponentRollup{}, "service_id = ?", id).Error
+	})
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	m.cancelDeadline(id)
+	for _, p := range probes {
+		m.cancelProbe(p.ID)
+	}
+	for _, t := range targets {
+		m.cancelScrape(t.ID)
+	}
+	m.cancelJob(id)
+	m.alertManager.Clear(id)
+	m.uptimeMu.Lock()
+	delete(m.uptimeCache, id)
+	m.uptimeMu.Unlock()
+	m.reportedMu.Lock()
+	delete(m.reported, id)
+	m.reportedMu.Unlock()
+	m.recordEvent(models.Event{
+		ServiceID: id,
+		Type:      models.EventDeleted,
+		Message:   fmt.Sprintf("Service '%s' deleted", svc.Name),
+	})
+	m.refreshVirtual(id)
+
+	log.Printf("Service deleted: %s (%s)", svc.Name, id)
+	return nil
+}
+
+// Archive hides a service from the list and suppresses its alerts.
+// The service is restored automatically when its axon reports again.
+func (m *Manager) Archive(id, reason string) error {
+	svc, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if svc.Archived {
+		return nil
+	}
+
+	now := time.Now()
+	err = m.db.Conn.Model(svc).
+		Select("archived", "archived_at").
+		Updates(&models.Service{Archived: true, ArchivedAt: &now}).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	m.cancelDeadline(id)
+	m.alertManager.Clear(id)
+	m.recordEvent(models.Event{
+		ServiceID: id,
+		Type:      models.EventArchived,
+		Status:    svc.Status,
+		Message:   reason,
+	})
+	return nil
+}
+
+// Unarchive makes an archived service visible again
+func (m *Manager) Unarchive(id string) error {
+	svc, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if !svc.Archived {
+		return nil
+	}
+
+	svc.Archived = false
+	svc.ArchivedAt = nil
+	if err := m.db.Conn.Model(svc).Select("archived", "archived_at").Updates(svc).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	m.rearm(svc)
+	m.recordEvent(models.Event{
+		ServiceID: id,
+		Type:      models.EventUnarchived,
+		Status:    svc.Status,
+		Message:   "Archive lifted manually",
+	})
+	return nil
+}
+
+// recordUnarchive logs that a heartbeat brought an archived service back
+func (m *Manager) recordUnarchive(existing *models.Service) {
+	if !existing.Archived {
+		return
+	}
+	m.recordEvent(models.Event{
+		ServiceID: existing.ID,
+		Type:      models.EventUnarchived,
+		Message:   "Service reported again",
+	})
+}
+
+// StartArchiveSweeper archives services that have been dead longer than
+// config.ArchiveAfter. Does nothing if ArchiveAfter is not set.
+func (m *Manager) StartArchiveSweeper(interval time.Duration) {
+	if m.config.ArchiveAfter <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			m.sweepStale()
+		}
+	}()
+}
+
+// sweepStale archives the services whose heartbeat TTL expired, or that are
+// registered offline, more than ArchiveAfter ago. The effective status is not
+// used: a dead service shows as maintenance or impacted too.
+func (m *Manager) sweepStale() {
+	cutoff := time.Now().Add(-m.config.ArchiveAfter).UTC()
+
+	var stale []models.Service
+	err := m.db.Conn.Select("id").
+		Where("archived = ? AND julianday(last_seen) < julianday(?)", false, cutoff).
+		Where("reported_status = 'offline' OR (ttl > 0 AND julianday(last_seen) + ttl / 86400.0 < julianday(?))", cutoff).
+		Find(&stale).Error
+	if err != nil {
+		log.Printf("Error sweeping stale services: %v", err)
+		return
+	}
+
+	for _, svc := range stale {
+		reason := fmt.Sprintf("Offline for more than %s", m.config.ArchiveAfter)
+		if err := m.Archive(svc.ID, reason); err != nil {
+			log.Printf("Error archiving %s: %v", svc.ID, err)
+		}
+	}
+
+	if len(stale) > 0 {
+		log.Printf("Archived %d stale services", len(stale))
+	}
+}
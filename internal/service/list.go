@@ -0,0 +1,211 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+const maxListLimit = 500
+
+// listSortColumns maps the sort keys of the list API to columns
+var listSortColumns = map[string]string{
+	"name":      "name",
+	"id":        "id",
+	"group":     `"group"`,
+	"status":    "status",
+	"last_seen": "last_seen",
+}
+
+// listCursor is the keyset position after the last row of a page
+type listCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// FilterError is a List error caused by the filter rather than the database
+type FilterError struct {
+	msg string
+}
+
+func (e *FilterError) Error() string {
+	return e.msg
+}
+
+func filterErrorf(format string, args ...any) error {
+	return &FilterError{msg: fmt.Sprintf(format, args...)}
+}
+
+// List returns the services matching the filter, ordered by filter.Sort and
+// then ID. If filter.Limit cuts the result, the cursor of the next page is
+// returned as well.
+func (m *Manager) List(filter models.ServiceFilter) ([]models.Service, string, error) {
+	key := strings.TrimPrefix(filter.Sort, "-")
+	if key == "" {
+		key = "name"
+	}
+	column, ok := listSortColumns[key]
+	if !ok {
+		return nil, "", filterErrorf("unknown sort key '%s' (use name, id, group, status or last_seen)", key)
+	}
+	desc := strings.HasPrefix(filter.Sort, "-")
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		return nil, "", filterErrorf("limit must be between 0 and %d", maxListLimit)
+	}
+
+	query := m.db.Conn.Model(&models.Service{})
+	if !filter.IncludeArchived {
+		query = query.Where("archived = ?", false)
+	}
+	if filter.Group != "" {
+		query = query.Where(`"group" = ?`, filter.Group)
+	}
+	if filter.Tag != "" {
+		// Tags are stored as a JSON array, match the encoded tag with its quotes
+		query = query.Where(`tags LIKE ? ESCAPE '\'`, jsonStringPattern(filter.Tag))
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where(`(name LIKE ? ESCAPE '\' OR id LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	if filter.Summary {
+		query = query.Omit("components", "layout", "markdown_docs")
+	}
+
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		var value any = c.Value
+		if key == "last_seen" {
+			t, err := time.Parse(time.RFC3339Nano, c.Value)
+			if err != nil {
+				return nil, "", filterErrorf("invalid cursor")
+			}
+			value = t
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op), value, value, c.ID)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	query = query.Order(fmt.Sprintf("%s %s, id %s", column, direction, direction))
+	if filter.Limit > 0 {
+		// One extra row tells whether there is a next page
+		query = query.Limit(filter.Limit + 1)
+	}
+
+	var services []models.Service
+	if err := query.Find(&services).Error; err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if filter.Limit > 0 && len(services) > filter.Limit {
+		services = services[:filter.Limit]
+		last := services[len(services)-1]
+		next = encodeCursor(listCursor{Value: sortValue(&last, key), ID: last.ID})
+	}
+
+	ptrs := make([]*models.Service, len(services))
+	for i := range services {
+		ptrs[i] = &services[i]
+	}
+	m.decorate(ptrs...)
+	m.decorateUptime(ptrs...)
+	return services, next, nil
+}
+
+// Groups returns the per-status service counts of every group
+func (m *Manager) Groups() ([]models.GroupSummary, error) {
+	var rows []struct {
+		Group  string
+		Status string
+		Count  int
+	}
+	err := m.db.Conn.Model(&models.Service{}).
+		Select(`"group", status, count(*) AS count`).
+		Where("archived = ?", false).
+		Group(`"group", status`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*models.GroupSummary)
+	for _, row := range rows {
+		g, ok := byName[row.Group]
+		if !ok {
+			g = &models.GroupSummary{Name: row.Group, Statuses: make(map[string]int)}
+			byName[row.Group] = g
+		}
+		g.Total += row.Count
+		g.Statuses[row.Status] += row.Count
+	}
+
+	groups := make([]models.GroupSummary, 0, len(byName))
+	for _, g := range byName {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+func sortValue(svc *models.Service, key string) string {
+	switch key {
+	case "id":
+		return svc.ID
+	case "group":
+		return svc.Group
+	case "status":
+		return svc.Status
+	case "last_seen":
+		return svc.LastSeen.Format(time.RFC3339Nano)
+	default:
+		return svc.Name
+	}
+}
+
+func encodeCursor(c listCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, filterErrorf("invalid cursor")
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, filterErrorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// jsonStringPattern returns a LIKE pattern (with ESCAPE '\') matching a JSON
+// array column that may contain s. Callers check the decoded value.
+func jsonStringPattern(s string) string {
+	encoded, _ := json.Marshal(s)
+	return "%" + escapeLike(string(encoded)) + "%"
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+// ListMaintenance returns all maintenance windows
+func (m *Manager) ListMaintenance() ([]models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	if err := m.db.Conn.Order("start").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range windows {
+		_, windows[i].Active = windows[i].ActiveAt(now)
+	}
+	return windows, nil
+}
+
+// SaveMaintenance creates or updates a maintenance window
+func (m *Manager) SaveMaintenance(w *models.MaintenanceWindow) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	if w.ID != 0 {
+		var existing models.MaintenanceWindow
+		if err := m.db.Conn.First(&existing, w.ID).Error; err != nil {
+			return err
+		}
+		w.CreatedAt = existing.CreatedAt
+	}
+	if err := m.db.Conn.Save(w).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	_, w.Active = w.ActiveAt(time.Now())
+	return nil
+}
+
+// DeleteMaintenance removes a maintenance window
+func (m *Manager) DeleteMaintenance(id uint) error {
+	result := m.db.Conn.Delete(&models.MaintenanceWindow{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// loadMaintenance returns all windows so callers checking many services
+// only query once
+func (m *Manager) loadMaintenance() []models.MaintenanceWindow {
+	var windows []models.MaintenanceWindow
+	if err := m.db.Conn.Find(&windows).Error; err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+	}
+	return windows
+}
+
+// maintenanceFor returns the state of the active window covering svc, if any.
+// When several windows overlap the one ending last wins.
+func maintenanceFor(windows []models.MaintenanceWindow, svc *models.Service, now time.Time) *models.MaintenanceState {
+	var state *models.MaintenanceState
+	for i := range windows {
+		w := &windows[i]
+		if !w.Matches(svc) {
+			continue
+		}
+		end, ok := w.ActiveAt(now)
+		if !ok {
+			continue
+		}
+		if state == nil || end.After(state.Ends) {
+			state = &models.MaintenanceState{WindowID: w.ID, Name: w.Name, Ends: end}
+		}
+	}
+	return state
+}
+
+// activeMaintenance returns the active window state for a single service
+func (m *Manager) activeMaintenance(svc *models.Service) *models.MaintenanceState {
+	return maintenanceFor(m.loadMaintenance(), svc, time.Now())
+}
+
+// decorate fills the computed (non-persisted) fields of services
+func (m *Manager) decorate(services ...*models.Service) {
+	windows := m.loadMaintenance()
+	now := time.Now()
+	for _, svc := range services {
+		svc.Maintenance = maintenanceFor(windows, svc, now)
+	}
+}
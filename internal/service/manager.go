@@ -4,13 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/wbw1537/synapse/internal/config"
 	"github.com/wbw1537/synapse/internal/db"
 	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/metrics"
 	"github.com/wbw1537/synapse/internal/models"
 	"github.com/wbw1537/synapse/internal/notification"
+	"github.com/wbw1537/synapse/internal/validation"
 	"gorm.io/gorm/clause"
 )
 
@@ -19,15 +24,41 @@ type Manager struct {
 	config       *config.Config
 	alertManager *notification.AlertManager
 	publishFunc  func(topic string, payload interface{}) error
+
+	deadlines  map[deadlineKey]*time.Timer // Heartbeat deadline per service/instance
+	deadlineMu sync.Mutex
+
+	uptimeCache map[string]cachedUptime // List summaries per service ID
+	uptimeMu    sync.Mutex
+
+	probeTimers  map[uint]*time.Timer // Next run per probe ID
+	probeMu      sync.Mutex
+	scrapeTimers map[uint]*time.Timer // Next scrape per target ID
+	scrapeMu     sync.Mutex
+	sourceMu     sync.Mutex             // Serializes sourced component writes
+	jobTimers    map[string]*time.Timer // Next schedule check per job service
+	jobMu        sync.Mutex
+	runMu        sync.Mutex // Serializes job state writes
+
+	reported   map[string]reportedValues // Monitored values per service, for prev
+	reportedMu sync.Mutex
 }
 
 func NewManager(database *db.Database, cfg *config.Config) *Manager {
 	sender := notification.NewSMTPSender(cfg)
-	return &Manager{
+	m := &Manager{
 		db:           database,
 		config:       cfg,
 		alertManager: notification.NewAlertManager(sender),
+		deadlines:    make(map[deadlineKey]*time.Timer),
+		uptimeCache:  make(map[string]cachedUptime),
+		probeTimers:  make(map[uint]*time.Timer),
+		scrapeTimers: make(map[uint]*time.Timer),
+		jobTimers:    make(map[string]*time.Timer),
+		reported:     make(map[string]reportedValues),
 	}
+	m.alertManager.SetEventRecorder(m.recordEvent)
+	return m
 }
 
 // SetPublisher sets the MQTT publish function
@@ -79,35 +110,147 @@ func (m *Manager) ExecuteAction(serviceID, actionID string) error {
 		"timestamp": time.Now().Format(time.RFC3339),
 	}
 	
-	return m.publishFunc(topic, payload)
+	if err := m.publishFunc(topic, payload); err != nil {
+		return err
+	}
+	metrics.ActionsPublished.Inc()
+
+	m.recordEvent(models.Event{
+		ServiceID: serviceID,
+		Type:      models.EventActionExecuted,
+		Message:   fmt.Sprintf("Action '%s' triggered", actionID),
+	})
+	return nil
 }
 
-// Upsert handles the registration/update logic
-func (m *Manager) Upsert(payload []byte) error {
+// Upsert handles the registration/update logic.
+// topicID is the service ID taken from the MQTT topic; it is empty for HTTP
+// registrations. Rejected payloads return a *validation.Error.
+func (m *Manager) Upsert(topicID string, payload []byte) (err error) {
+	metrics.Upserts.Inc()
+	defer func() {
+		if err != nil {
+			metrics.UpsertErrors.Inc()
+		}
+	}()
+
 	var p models.ServicePayload
 	if err := json.Unmarshal(payload, &p); err != nil {
-		return fmt.Errorf("invalid json: %w", err)
+		report := &validation.Report{}
+		report.Errorf("", "invalid_json", "invalid json: %v", err)
+		return m.reject(topicID, report)
 	}
 
 	// 1. Validation
-	if p.ID == "" {
-		return fmt.Errorf("service id is required")
+	replyID := p.ID
+	if topicID != "" {
+		replyID = topicID
+	}
+	if err := m.authorize(replyID, p.AuthToken); err != nil {
+		report := &validation.Report{}
+		report.Errorf("auth_token", "unauthorized", "%v", err)
+		return m.reject(replyID, report)
 	}
-	if p.AuthToken != m.config.AuthToken {
-		return fmt.Errorf("invalid auth_token")
+
+	// Only Core itself registers services on behalf of other sources
+	p.Source, p.SourceID = "", ""
+	return m.register(topicID, &p, payload)
+}
+
+// register validates and stores an authorized discovery payload. Core's own
+// sources (scrape targets, ...) register through here like axons do, with
+// p.Source set.
+func (m *Manager) register(topicID string, p *models.ServicePayload, payload []byte) error {
+	replyID := p.ID
+	if topicID != "" {
+		replyID = topicID
+	}
+
+	report := &validation.Report{}
+	validation.CheckPayload(topicID, p, report)
+	if report.HasErrors() {
+		return m.reject(replyID, report)
 	}
 
 	// 2. Prepare Model
 	svc := p.Service
 	svc.LastSeen = time.Now()
+	// The axon's status is kept as reported, the effective one is derived below
+	svc.ReportedStatus = svc.Status
+	if svc.ReportedStatus == "" {
+		svc.ReportedStatus = "online"
+	}
+	svc.Firing = nil
+	// Warnings don't block the registration, they are flagged on the service
+	svc.Issues = report.Warnings()
+	for _, issue := range svc.Issues {
+		log.Printf("Validation warning (svc=%s) %s: %s", svc.ID, issue.Path, issue.Message)
+	}
+	// Archiving is server-side state: a reporting service is never archived
+	svc.Archived = false
+	svc.ArchivedAt = nil
+	// Virtual services are defined in Core, an axon can't claim the flag
+	svc.Virtual = false
+	// The job state is derived from the runs, not reported
+	svc.Job = nil
+	// The payload's components are its own, probe components are merged below
+	for compID, comp := range svc.Components {
+		comp.Source = ""
+		svc.Components[compID] = comp
+	}
 
-	// 2.5 Merge with existing state (for log_stream, etc.)
-	if existing, err := m.Get(svc.ID); err == nil && existing != nil {
+	// 2.5 Merge with existing state (for log_stream, etc.).
+	// Replicas keep their own values, the service shows the aggregate.
+	existing, err := m.Get(svc.ID)
+	if err != nil {
+		existing = nil
+	}
+	if existing != nil && existing.Virtual {
+		report.Errorf("id", "id_taken", "service '%s' is a virtual service", svc.ID)
+		return m.reject(replyID, report)
+	}
+	if existing != nil && p.Instance == "" && len(existing.Instances) > 0 {
+		report.Errorf("instance", "instance_required", "service '%s' has %d instances, set 'instance'", svc.ID, len(existing.Instances))
+		return m.reject(replyID, report)
+	}
+	var inst *models.ServiceInstance
+	if p.Instance != "" {
+		values := make(map[string]any, len(svc.Components))
+		for compID, comp := range svc.Components {
+			if comp.Value != nil {
+				values[compID] = comp.Value
+			}
+		}
+		inst, err = m.reportInstance(&svc, p.Instance, p.Status, p.Message, p.TTL, values)
+		if err != nil {
+			return err
+		}
+	} else if existing != nil {
 		m.mergeComponents(existing, &svc)
 	}
+	if existing != nil {
+		// Components written by Core (probes, ...) survive the registration
+		keepSourced(existing, &svc)
+		// Kept for the monitor evaluation below, the column is not written
+		svc.Job = existing.Job
+	}
+	m.fillJobHistory(&svc)
 
-	// 3. Upsert into DB
-	err := m.db.Conn.Clauses(clause.OnConflict{
+	// 2.6 Apply the policy layer (user overrides win over the axon payload)
+	m.applyOverride(&svc)
+
+	// 2.7 Check monitors and derive the effective status
+	var prevFiring []models.FiringMonitor
+	if existing != nil {
+		prevFiring = existing.Firing
+	}
+	m.evaluateMonitors(&svc, prevFiring)
+	svc.Status = models.EffectiveStatus(svc.ReportedStatus, svc.Firing)
+	m.applyImpact(&svc)
+
+	// 3. Upsert into DB. The job column belongs to ReportRun and checkJob,
+	// which write it under runMu.
+	err = m.db.Conn.Omit("job").Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "id"}},
 		UpdateAll: true,
 	}).Create(&svc).Error
@@ -116,13 +259,192 @@ func (m *Manager) Upsert(payload []byte) error {
 		return fmt.Errorf("db error: %w", err)
 	}
 
-	// 4. Check Monitors
-	m.evaluateMonitors(&svc)
+	// 3.4 Version the raw definition for recovery kits
+	m.recordSnapshot(svc.ID, payload)
+
+	// 3.5 Record lifecycle events
+	if existing == nil {
+		m.recordEvent(models.Event{
+			ServiceID: svc.ID,
+			Type:      models.EventRegistered,
+			Status:    svc.Status,
+			Message:   fmt.Sprintf("Service '%s' registered", svc.Name),
+		})
+	} else {
+		m.recordStatusChange(&svc, existing.Status, statusReason(&svc))
+		m.recordUnarchive(existing)
+	}
+
+	// 4. Re-arm the heartbeat deadline
+	if inst != nil {
+		m.heartbeatInstance(&svc, inst)
+	} else {
+		m.heartbeat(&svc)
+	}
+
+	// 5. Record numeric history
+	m.recordHistory(&svc)
+
+	// 5.5 Check the schedule of jobs
+	if svc.Schedule != nil {
+		m.checkJob(svc.ID)
+	} else {
+		m.cancelJob(svc.ID)
+	}
+
+	// 6. Re-evaluate the virtual services reading this one.
+	// A status change already did through recordStatusChange.
+	if existing == nil || existing.Status == svc.Status {
+		m.refreshVirtual(svc.ID)
+	}
 
 	log.Printf("Service registered/updated: %s (%s)", svc.Name, svc.ID)
 	return nil
 }
 
+// UpdateState applies a partial state update to an already registered service.
+// Only component values (and optionally status/message) are carried, so the
+// axon does not need to re-send its layout and docs on every tick.
+func (m *Manager) UpdateState(id string, payload []byte) error {
+	var p models.StatePayload
+	report := &validation.Report{}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		report.Errorf("", "invalid_json", "invalid json: %v", err)
+		return m.reject(id, report)
+	}
+
+	// 1. Validation
+	if err := m.authorize(id, p.AuthToken); err != nil {
+		report.Errorf("auth_token", "unauthorized", "%v", err)
+		return m.reject(id, report)
+	}
+
+	existing, err := m.Get(id)
+	if err != nil {
+		report.Errorf("", "not_registered", "service '%s' is not registered", id)
+		return m.reject(id, report)
+	}
+	if existing.Virtual {
+		report.Errorf("", "virtual_service", "service '%s' is a virtual service", id)
+		return m.reject(id, report)
+	}
+	validation.CheckInstance(p.Instance, report)
+	if p.Instance == "" && len(existing.Instances) > 0 {
+		report.Errorf("instance", "instance_required", "service '%s' has %d instances, set 'instance'", id, len(existing.Instances))
+	}
+	if p.Status != "" && !validation.KnownStatuses[p.Status] {
+		report.Errorf("status", "invalid_status", "unknown status '%s' (use online, warning, error, offline or maintenance)", p.Status)
+	}
+	for compID := range p.Components {
+		if _, ok := existing.Components[compID]; !ok {
+			report.Errorf("components."+compID, "unknown_component", "component '%s' not found for service '%s'", compID, id)
+		}
+	}
+	if report.HasErrors() {
+		return m.reject(id, report)
+	}
+
+	svc := *existing
+	svc.Components = make(map[string]models.Component, len(existing.Components))
+	for compID, comp := range existing.Components {
+		svc.Components[compID] = comp
+	}
+	svc.LastSeen = time.Now()
+	svc.Archived = false
+	svc.ArchivedAt = nil
+
+	// 2. Build the update from the stored definitions.
+	// Only the reported components take part in the merge, so a log_stream
+	// appends the new line instead of re-appending its whole history.
+	var inst *models.ServiceInstance
+	if p.Instance != "" {
+		inst, err = m.reportInstance(&svc, p.Instance, p.Status, p.Message, 0, p.Components)
+		if err != nil {
+			return err
+		}
+	} else {
+		incoming := &models.Service{Components: make(map[string]models.Component, len(p.Components))}
+		for compID, value := range p.Components {
+			comp := existing.Components[compID]
+			comp.Value = value
+			incoming.Components[compID] = comp
+		}
+		m.mergeComponents(existing, incoming)
+		for compID, comp := range incoming.Components {
+			svc.Components[compID] = comp
+		}
+
+		if p.Status != "" {
+			svc.ReportedStatus = p.Status
+		} else if svc.ReportedStatus == "" {
+			// Stored before the reported status was tracked separately
+			svc.ReportedStatus = existing.Status
+			if svc.ReportedStatus == "offline" || svc.ReportedStatus == "maintenance" {
+				svc.ReportedStatus = "online"
+			}
+		} else if svc.ReportedStatus == "offline" {
+			// Imported services are registered offline, a state update
+			// means their axon is up
+			svc.ReportedStatus = "online"
+		}
+		if p.Message != "" {
+			svc.Message = p.Message
+		}
+	}
+
+	// 2.5 Check monitors and derive the effective status.
+	// A state update is a heartbeat too, so a TTL expiry is cleared.
+	m.evaluateMonitors(&svc, existing.Firing)
+	svc.Status = models.EffectiveStatus(svc.ReportedStatus, svc.Firing)
+	m.applyImpact(&svc)
+
+	// 3. Persist only the state columns
+	err = m.db.Conn.Model(&svc).
+		Select("status", "reported_status", "firing", "impacted_by", "issues", "message", "components", "last_seen", "archived", "archived_at").
+		Updates(&svc).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	m.recordStatusChange(&svc, existing.Status, statusReason(&svc))
+	m.recordUnarchive(existing)
+
+	// 4. Re-arm the heartbeat deadline
+	if inst != nil {
+		m.heartbeatInstance(&svc, inst)
+	} else {
+		m.heartbeat(&svc)
+	}
+
+	// 5. Record numeric history of the reported components only
+	reported := make(map[string]models.Component, len(p.Components))
+	for compID := range p.Components {
+		if comp, ok := svc.Components[compID]; ok {
+			reported[compID] = comp
+		}
+	}
+	m.recordHistory(&models.Service{ID: svc.ID, LastSeen: svc.LastSeen, Components: reported})
+
+	// 6. Re-evaluate the virtual services reading this one
+	if existing.Status == svc.Status {
+		m.refreshVirtual(svc.ID)
+	}
+
+	return nil
+}
+
+// reject publishes the validation report to the axon's error topic and
+// returns it as a *validation.Error
+func (m *Manager) reject(serviceID string, report *validation.Report) error {
+	verr := &validation.Error{ServiceID: serviceID, Report: report}
+	if serviceID != "" && m.publishFunc != nil {
+		topic := fmt.Sprintf("synapse/v1/error/%s", serviceID)
+		if err := m.publishFunc(topic, verr.Document()); err != nil {
+			log.Printf("Failed to publish error reply to %s: %v", topic, err)
+		}
+	}
+	return verr
+}
+
 // mergeComponents preserves state from existing components into the new update
 func (m *Manager) mergeComponents(existing, incoming *models.Service) {
 	if existing.Components == nil {
@@ -144,6 +466,9 @@ func (m *Manager) mergeComponents(existing, incoming *models.Service) {
 					for _, s := range v {
 						logs = append(logs, s)
 					}
+				case string:
+					// Stored unwrapped by the very first registration
+					logs = append(logs, v)
 				}
 
 				// Append new value if it's a string
@@ -176,58 +501,93 @@ func (m *Manager) mergeComponents(existing, incoming *models.Service) {
 	}
 }
 
-func (m *Manager) evaluateMonitors(svc *models.Service) {
+// evaluateMonitors runs the monitors of a service, notifies on transitions and
+// sets svc.Firing. prev is the stored firing list, so a monitor that keeps
+// firing keeps its start time.
+func (m *Manager) evaluateMonitors(svc *models.Service, prev []models.FiringMonitor) {
+	if svc.Archived {
+		return
+	}
+
+	since := make(map[string]time.Time, len(prev))
+	for _, f := range prev {
+		since[fmt.Sprintf("%s:m%d", f.ComponentID, f.Monitor)] = f.Since
+	}
+	now := time.Now()
+	var firing []models.FiringMonitor
+
+	// Notifications are silenced during maintenance, state is still tracked
+	suppressed := ""
+	if mw := m.activeMaintenance(svc); mw != nil {
+		suppressed = fmt.Sprintf("maintenance window '%s'", mw.Name)
+	}
+
+	env := monitorEnv(svc)
+	prevValues := m.prevValues(svc)
+
+	var monitorIssues []models.ValidationIssue
 	for compID, comp := range svc.Components {
+		env.For(componentEnv(comp), prevValues[compID])
 		for mIdx, monitor := range comp.Monitors {
-			triggered, err := evaluator.Evaluate(monitor.Condition, comp.Value)
+			triggered, err := evaluator.Evaluate(monitor.Condition, env)
 			if err != nil {
-				log.Printf("Monitor evaluation error (svc=%s, comp=%s): %v", svc.ID, compID, err)
+				// expr appends a source excerpt on further lines
+				msg, _, _ := strings.Cut(err.Error(), "\n")
+				monitorIssues = append(monitorIssues, models.ValidationIssue{
+					Path:     fmt.Sprintf("components.%s.monitors[%d].condition", compID, mIdx),
+					Severity: validation.SeverityWarning,
+					Code:     "monitor_error",
+					Message:  msg,
+				})
 				continue
 			}
+			if triggered {
+				start, ok := since[fmt.Sprintf("%s:m%d", compID, mIdx)]
+				if !ok {
+					start = now
+				}
+				firing = append(firing, models.FiringMonitor{
+					ComponentID: compID,
+					Monitor:     mIdx,
+					Severity:    monitor.Severity,
+					Message:     monitor.Message,
+					Since:       start,
+				})
+			}
 
 			// Unique key for state tracking
-			key := fmt.Sprintf("%s:%s:m%d", svc.ID, compID, mIdx)
-			m.alertManager.CheckAndAlert(key, triggered, monitor.Severity, monitor.Message, svc.Name)
+			m.alertManager.CheckAndAlert(notification.Alert{
+				Key:         fmt.Sprintf("%s:%s:m%d", svc.ID, compID, mIdx),
+				ServiceID:   svc.ID,
+				ServiceName: svc.Name,
+				ComponentID: compID,
+				Severity:    monitor.Severity,
+				Message:     monitor.Message,
+				Suppressed:  suppressed,
+				Upstream:    svc.DependsOn,
+			}, triggered)
 		}
 	}
-}
 
-// StartTTLMonitor checks for expired services
-func (m *Manager) StartTTLMonitor(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	go func() {
-		for range ticker.C {
-			m.checkTTL()
-		}
-	}()
-}
+	firing = append(firing, m.jobFiring(svc, since, now, suppressed)...)
 
-func (m *Manager) checkTTL() {
-	query := `
-		UPDATE services 
-		SET status = 'offline', updated_at = ?
-		WHERE status != 'offline' 
-		AND datetime(last_seen) < datetime(?, '-' || ttl || ' seconds')
-	`
-	
-	now := time.Now()
-	result := m.db.Conn.Exec(query, now, now)
-	
-	if result.Error != nil {
-		log.Printf("Error checking TTL: %v", result.Error)
-		return
-	}
-	
-	if result.RowsAffected > 0 {
-		log.Printf("Marked %d services as offline", result.RowsAffected)
+	// Evaluation errors are flagged on the service, replacing the last ones
+	issues := svc.Issues[:0:0]
+	for _, issue := range svc.Issues {
+		if issue.Code != "monitor_error" {
+			issues = append(issues, issue)
+		}
 	}
-}
+	sort.Slice(monitorIssues, func(i, j int) bool { return monitorIssues[i].Path < monitorIssues[j].Path })
+	svc.Issues = append(issues, monitorIssues...)
 
-// List returns all services
-func (m *Manager) List() ([]models.Service, error) {
-	var services []models.Service
-	result := m.db.Conn.Find(&services)
-	return services, result.Error
+	sort.Slice(firing, func(i, j int) bool {
+		if firing[i].ComponentID != firing[j].ComponentID {
+			return firing[i].ComponentID < firing[j].ComponentID
+		}
+		return firing[i].Monitor < firing[j].Monitor
+	})
+	svc.Firing = firing
 }
 
 // Get returns a single service by ID
@@ -237,5 +597,9 @@ func (m *Manager) Get(id string) (*models.Service, error) {
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	m.decorate(&svc)
+	if err := m.db.Conn.Where("service_id = ?", id).Order("instance").Find(&svc.Instances).Error; err != nil {
+		return nil, err
+	}
 	return &svc, nil
 }
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/wbw1537/synapse/internal/config"
+	"github.com/wbw1537/synapse/internal/db"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	conn, err := db.Connect(t.TempDir() + "/synapse.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(conn, &config.Config{AuthToken: "secret", AllowGlobalToken: true})
+}
+
+func TestRegisterDropsVirtualFlag(t *testing.T) {
+	m := newTestManager(t)
+	payload := `{"auth_token":"secret","id":"app","name":"App","ttl":60,"virtual":true,"components":{}}`
+	if err := m.Upsert("", []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := m.Get("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Virtual {
+		t.Fatal("axon registration stored the virtual flag")
+	}
+	// The axon can register again
+	if err := m.Upsert("", []byte(payload)); err != nil {
+		t.Fatalf("second registration rejected: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/metrics"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// exportedStatuses are the states of the synapse_service_status enum
+var exportedStatuses = []string{"online", "warning", "error", "offline", "maintenance", "impacted"}
+
+// exportedSeverities are the severities firing monitors are counted by
+var exportedSeverities = []string{"info", "warning", "error", "critical"}
+
+// WriteMetrics writes the state of all non-archived services and the
+// internal counters in the Prometheus text format
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	var services []models.Service
+	err := m.db.Conn.Omit("markdown_docs", "layout").
+		Where("archived = ?", false).
+		Order("id").
+		Find(&services).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	now := time.Now()
+
+	metrics.WriteHeader(w, "synapse_service_status", "gauge", "Effective status of the service, 1 for the current state.")
+	for _, svc := range services {
+		for _, status := range exportedStatuses {
+			value := 0.0
+			if svc.Status == status {
+				value = 1
+			}
+			metrics.WriteSample(w, "synapse_service_status", []string{"service", svc.ID, "group", svc.Group, "status", status}, value)
+		}
+	}
+
+	metrics.WriteHeader(w, "synapse_service_last_seen_age_seconds", "gauge", "Seconds since the service last reported.")
+	for _, svc := range services {
+		metrics.WriteSample(w, "synapse_service_last_seen_age_seconds", []string{"service", svc.ID, "group", svc.Group}, now.Sub(svc.LastSeen).Seconds())
+	}
+
+	metrics.WriteHeader(w, "synapse_service_firing_monitors", "gauge", "Firing monitors of the service by severity.")
+	for _, svc := range services {
+		counts := make(map[string]int, len(exportedSeverities))
+		for _, f := range svc.Firing {
+			counts[f.Severity]++
+		}
+		for _, severity := range exportedSeverities {
+			metrics.WriteSample(w, "synapse_service_firing_monitors", []string{"service", svc.ID, "group", svc.Group, "severity", severity}, float64(counts[severity]))
+		}
+	}
+
+	metrics.WriteHeader(w, "synapse_component_value", "gauge", "Numeric component values.")
+	for _, svc := range services {
+		ids := make([]string, 0, len(svc.Components))
+		for compID := range svc.Components {
+			ids = append(ids, compID)
+		}
+		sort.Strings(ids)
+		for _, compID := range ids {
+			comp := svc.Components[compID]
+			value, ok := toFloat(comp.Value)
+			if !ok {
+				continue
+			}
+			metrics.WriteSample(w, "synapse_component_value", []string{"service", svc.ID, "group", svc.Group, "component", compID, "unit", comp.Unit}, value)
+		}
+	}
+
+	metrics.WriteHeader(w, "synapse_expr_cached_programs", "gauge", "Compiled expressions in the program cache.")
+	metrics.WriteSample(w, "synapse_expr_cached_programs", nil, float64(evaluator.CachedPrograms()))
+
+	metrics.WriteCounters(w)
+	return nil
+}
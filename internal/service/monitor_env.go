@@ -0,0 +1,73 @@
+package service
+
+import (
+	"time"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// reportedValues are the monitored component values of a service at its
+// last two reports, for `prev` in monitor conditions. Like the alert states
+// they live in memory, so prev is nil for the first report after a restart.
+type reportedValues struct {
+	seen   time.Time      // LastSeen of the report the values belong to
+	values map[string]any // Current values
+	prev   map[string]any // Values at the report before
+}
+
+// monitorEnv returns the monitor environment of a service
+func monitorEnv(svc *models.Service) *evaluator.MonitorEnv {
+	components := make(map[string]evaluator.ComponentEnv, len(svc.Components))
+	for compID, comp := range svc.Components {
+		components[compID] = componentEnv(comp)
+	}
+	return evaluator.NewMonitorEnv(evaluator.ServiceEnv{
+		ID:    svc.ID,
+		Name:  svc.Name,
+		Group: svc.Group,
+		Tags:  svc.Tags,
+	}, components)
+}
+
+func componentEnv(comp models.Component) evaluator.ComponentEnv {
+	env := evaluator.ComponentEnv{
+		Value:      comp.Value,
+		Min:        comp.Min,
+		Max:        comp.Max,
+		Unit:       comp.Unit,
+		Thresholds: comp.Thresholds,
+	}
+	// Monitors see the max the gauge is rendered with
+	if comp.Type == "gauge" {
+		env.Max = comp.GaugeMax()
+	}
+	return env
+}
+
+// prevValues returns the values the monitored components of svc had at the
+// report before the current one. A new report is told apart from a
+// re-evaluation (override change, ...) by its LastSeen.
+func (m *Manager) prevValues(svc *models.Service) map[string]any {
+	values := make(map[string]any)
+	for compID, comp := range svc.Components {
+		if len(comp.Monitors) > 0 {
+			values[compID] = comp.Value
+		}
+	}
+
+	m.reportedMu.Lock()
+	defer m.reportedMu.Unlock()
+
+	r, ok := m.reported[svc.ID]
+	switch {
+	case !ok:
+		r = reportedValues{seen: svc.LastSeen}
+	case !r.seen.Equal(svc.LastSeen):
+		r.seen = svc.LastSeen
+		r.prev = r.values
+	}
+	r.values = values
+	m.reported[svc.ID] = r
+	return r.prev
+}
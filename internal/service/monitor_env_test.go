@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+func TestGaugeWithoutMaxUsesDefault(t *testing.T) {
+	gauge := models.Component{Type: "gauge", Value: 42.0}
+	svc := &models.Service{ID: "nas", Components: map[string]models.Component{"disk": gauge}}
+
+	env := monitorEnv(svc).For(componentEnv(gauge), nil)
+	firing, err := evaluator.Evaluate("value > 0.9 * max", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firing {
+		t.Fatal("42 of the default max 100 fired 'value > 0.9 * max'")
+	}
+
+	firing, err = evaluator.Evaluate("max == 100", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !firing {
+		t.Fatal("gauge without max did not see the default max of 100")
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+	"gorm.io/gorm"
+)
+
+// GetOverride returns the policy override for a service, or nil if none is set
+func (m *Manager) GetOverride(serviceID string) (*models.ServiceOverride, error) {
+	var o models.ServiceOverride
+	result := m.db.Conn.Where("service_id = ?", serviceID).Limit(1).Find(&o)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &o, nil
+}
+
+// SetOverride replaces the policy override for a service and applies it to the
+// stored state right away, without waiting for the next heartbeat. Fields the
+// new override no longer covers get their axon-defined values back.
+func (m *Manager) SetOverride(serviceID string, o *models.ServiceOverride) error {
+	svc, err := m.Get(serviceID)
+	if err != nil {
+		return err
+	}
+
+	o.ServiceID = serviceID
+	report := &validation.Report{}
+	validation.CheckOverride(o, report)
+	if !report.HasErrors() {
+		if cycle := m.dependencyCycle(serviceID, o.DependsOn); cycle != nil {
+			report.Errorf("depends_on", "cycle", "services depend on each other in a cycle: %v", cycle)
+		}
+	}
+	if report.HasErrors() {
+		return &validation.Error{ServiceID: serviceID, Report: report}
+	}
+
+	prev, err := m.GetOverride(serviceID)
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if prev != nil {
+		o.CreatedAt = prev.CreatedAt
+	}
+	if err := m.db.Conn.Save(o).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return m.reapplyOverride(svc, o)
+}
+
+// dependencyCycle returns the path of a cycle through id, if it depended on
+// deps instead of its current dependencies
+func (m *Manager) dependencyCycle(id string, deps []string) []string {
+	var services []models.Service
+	if err := m.db.Conn.Select("id", "depends_on").Find(&services).Error; err != nil {
+		log.Printf("Failed to load dependencies: %v", err)
+		return nil
+	}
+	graph := make(map[string][]string, len(services)+1)
+	for _, svc := range services {
+		graph[svc.ID] = svc.DependsOn
+	}
+	graph[id] = deps
+
+	var visit func(current string, path []string) []string
+	visit = func(current string, path []string) []string {
+		for _, dep := range graph[current] {
+			if dep == id {
+				return append(path, dep)
+			}
+			if slices.Contains(path, dep) {
+				continue
+			}
+			if cycle := visit(dep, append(path, dep)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(id, []string{id})
+}
+
+// DeleteOverride removes the policy override for a service and restores the
+// axon-defined values right away.
+func (m *Manager) DeleteOverride(serviceID string) error {
+	result := m.db.Conn.Delete(&models.ServiceOverride{}, "service_id = ?", serviceID)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	svc, err := m.Get(serviceID)
+	if err != nil {
+		return err
+	}
+	return m.reapplyOverride(svc, nil)
+}
+
+// reapplyOverride resets the stored service to its last registration,
+// merges o over it and re-derives the status
+func (m *Manager) reapplyOverride(svc *models.Service, o *models.ServiceOverride) error {
+	m.restoreDefinition(svc)
+	o.Apply(svc)
+	if err := m.saveOverridden(svc); err != nil {
+		return err
+	}
+
+	// Overridden monitors and dependencies may change the effective status.
+	// A TTL expiry stands until the axon reports again.
+	prevStatus := svc.Status
+	m.evaluateMonitors(svc, svc.Firing)
+	svc.Status = m.baseStatus(svc, time.Now())
+	m.applyImpact(svc)
+	if err := m.db.Conn.Model(svc).Select("status", "firing", "impacted_by", "issues").Updates(svc).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	m.recordStatusChange(svc, prevStatus, statusReason(svc))
+	return nil
+}
+
+// restoreDefinition puts the overridable fields of the latest snapshot back
+// on the service. Component values and components written by Core are kept.
+func (m *Manager) restoreDefinition(svc *models.Service) {
+	def, _, err := m.SnapshotService(svc.ID, 0)
+	if err != nil {
+		log.Printf("No definition to restore for %s: %v", svc.ID, err)
+		return
+	}
+
+	svc.Name = def.Name
+	svc.Icon = def.Icon
+	svc.Group = def.Group
+	svc.Tags = def.Tags
+	svc.MarkdownDocs = def.MarkdownDocs
+	svc.DependsOn = def.DependsOn
+	for compID, comp := range svc.Components {
+		orig, ok := def.Components[compID]
+		if !ok || comp.Source != "" {
+			continue
+		}
+		comp.Monitors = orig.Monitors
+		svc.Components[compID] = comp
+	}
+}
+
+// applyOverride merges the stored override (if any) over an incoming service
+func (m *Manager) applyOverride(svc *models.Service) {
+	o, err := m.GetOverride(svc.ID)
+	if err != nil {
+		log.Printf("Failed to load override for %s: %v", svc.ID, err)
+	}
+	o.Apply(svc)
+}
+
+func (m *Manager) saveOverridden(svc *models.Service) error {
+	err := m.db.Conn.Model(svc).
+		Select("name", "icon", "group", "tags", "markdown_docs", "components", "depends_on", "overridden").
+		Updates(svc).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
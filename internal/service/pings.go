@@ -0,0 +1,196 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+// sourcePing marks services registered by a ping check
+const sourcePing = "ping"
+
+// pingMapping styles the last_run status_indicator of a ping check
+var pingMapping = map[string]models.StatusState{
+	models.PingSuccess: {Text: "Succeeded", Color: "green", Icon: "check-circle"},
+	models.PingFail:    {Text: "Failed", Color: "red", Icon: "x-circle"},
+	models.PingStart:   {Text: "Running", Color: "blue", Icon: "loader", Animate: true},
+}
+
+// ListPingChecks returns all ping checks. Tokens are never included.
+func (m *Manager) ListPingChecks() ([]models.PingCheck, error) {
+	var checks []models.PingCheck
+	if err := m.db.Conn.Order("service_id").Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	for i := range checks {
+		checks[i].Token = ""
+	}
+	return checks, nil
+}
+
+// SavePingCheck creates or updates a ping check. New checks get a token, set
+// on c.Token; an update keeps the old one and does not return it.
+func (m *Manager) SavePingCheck(c *models.PingCheck) error {
+	c.Defaults()
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	created := c.ID == 0
+	if !created {
+		var existing models.PingCheck
+		if err := m.db.Conn.First(&existing, c.ID).Error; err != nil {
+			return err
+		}
+		c.Token = existing.Token
+		c.CreatedAt = existing.CreatedAt
+		c.LastPing, c.LastStart, c.LastResult = existing.LastPing, existing.LastStart, existing.LastResult
+	} else {
+		token, err := randomHex(16)
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+		c.Token = token
+	}
+
+	var count int64
+	err := m.db.Conn.Model(&models.PingCheck{}).
+		Where("service_id = ? AND id != ?", c.ServiceID, c.ID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("service '%s' already has a ping check", c.ServiceID)
+	}
+	if svc, err := m.Get(c.ServiceID); err == nil && svc.Source != sourcePing {
+		return fmt.Errorf("service '%s' is already registered by another source", c.ServiceID)
+	}
+
+	if err := m.db.Conn.Save(c).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if !created {
+		c.Token = ""
+	}
+	return nil
+}
+
+// RotatePingCheck replaces the token of a ping check. The old token stops
+// working immediately.
+func (m *Manager) RotatePingCheck(id uint) (*models.PingCheck, error) {
+	var c models.PingCheck
+	if err := m.db.Conn.First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	if err := m.db.Conn.Model(&c).Update("token", token).Error; err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	c.Token = token
+	return &c, nil
+}
+
+// DeletePingCheck removes a ping check. Its service is left to expire
+// through its TTL.
+func (m *Manager) DeletePingCheck(id uint) error {
+	result := m.db.Conn.Delete(&models.PingCheck{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Ping records a ping of the check with the given token and registers its
+// service. A start ping only gives the run grace seconds to finish; success
+// and fail re-arm the TTL for the next run. The body, if any, is appended to
+// the "log" component. Unknown tokens return gorm.ErrRecordNotFound.
+func (m *Manager) Ping(token, kind string, body []byte) error {
+	var c models.PingCheck
+	if err := m.db.Conn.First(&c, "token = ?", token).Error; err != nil {
+		return err
+	}
+
+	existing, err := m.Get(c.ServiceID)
+	if err != nil {
+		existing = nil
+	}
+	if existing != nil && existing.Source != sourcePing {
+		return fmt.Errorf("service '%s' is registered by another source", c.ServiceID)
+	}
+
+	now := time.Now()
+	svc := models.Service{
+		ID:          c.ServiceID,
+		Name:        c.Name,
+		Group:       c.Group,
+		Description: c.Description,
+		Source:      sourcePing,
+		Status:      "online",
+		TTL:         c.TTL(),
+		Layout: models.LayoutSchema{
+			Type: "sections",
+			Root: []models.LayoutSection{{Type: "section", Title: "Job", Children: []string{"last_run", "duration", "log"}}},
+		},
+		Components: map[string]models.Component{
+			"last_run": {Type: "status_indicator", Label: "Last run", Value: kind, Mapping: pingMapping},
+			"duration": {Type: "stat", Label: "Duration", Unit: "s"},
+			"log":      {Type: "log_stream", Label: "Output", MaxItems: c.MaxLog},
+		},
+	}
+
+	duration := svc.Components["duration"]
+	switch kind {
+	case models.PingStart:
+		// The run must finish within the grace time. Until then the
+		// service keeps the outcome of the previous run.
+		svc.TTL = c.Grace
+		svc.Message = fmt.Sprintf("Running since %s", now.UTC().Format(time.RFC3339))
+		if existing != nil {
+			svc.Status = existing.ReportedStatus
+			duration.Value = existing.Components["duration"].Value
+		}
+	case models.PingFail:
+		svc.Status = "error"
+		svc.Message = "Last run failed"
+	default:
+		svc.Message = "Last run succeeded"
+	}
+	if kind != models.PingStart && c.LastStart != nil {
+		duration.Value = now.Sub(*c.LastStart).Round(time.Millisecond).Seconds()
+	}
+	svc.Components["duration"] = duration
+	if text := strings.TrimSpace(string(body)); text != "" {
+		logComp := svc.Components["log"]
+		logComp.Value = text
+		svc.Components["log"] = logComp
+	}
+
+	p := models.ServicePayload{Service: svc}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := m.register("", &p, payload); err != nil {
+		return err
+	}
+
+	updates := map[string]any{"last_ping": now, "last_result": kind, "last_start": nil}
+	if kind == models.PingStart {
+		updates["last_start"] = now
+	}
+	if err := m.db.Conn.Model(&c).Select("last_ping", "last_result", "last_start").Updates(updates).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
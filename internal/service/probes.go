@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/probe"
+	"gorm.io/gorm"
+)
+
+// sourceProbe marks services created by a probe
+const sourceProbe = "probe"
+
+// probeSection is the layout section probe components are listed under
+const probeSection = "Probes"
+
+// probeMapping styles the status_indicator of a probe
+var probeMapping = map[string]models.StatusState{
+	"up":   {Text: "Up", Color: "green", Icon: "check-circle"},
+	"down": {Text: "Down", Color: "red", Icon: "x-circle"},
+}
+
+// ListProbes returns the probes, optionally of a single service
+func (m *Manager) ListProbes(serviceID string) ([]models.Probe, error) {
+	query := m.db.Conn.Order("service_id, name")
+	if serviceID != "" {
+		query = query.Where("service_id = ?", serviceID)
+	}
+	var probes []models.Probe
+	if err := query.Find(&probes).Error; err != nil {
+		return nil, err
+	}
+	return probes, nil
+}
+
+// SaveProbe creates or updates a probe and runs it right away
+func (m *Manager) SaveProbe(p *models.Probe) error {
+	p.Defaults()
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	var moved *models.Probe
+	if p.ID != 0 {
+		var existing models.Probe
+		if err := m.db.Conn.First(&existing, p.ID).Error; err != nil {
+			return err
+		}
+		p.CreatedAt = existing.CreatedAt
+		p.LastRun, p.LastUp, p.LastLatency, p.LastError = existing.LastRun, existing.LastUp, existing.LastLatency, existing.LastError
+		if existing.ServiceID != p.ServiceID || existing.Name != p.Name {
+			moved = &existing
+		}
+	}
+
+	var count int64
+	err := m.db.Conn.Model(&models.Probe{}).
+		Where("service_id = ? AND name = ? AND id != ?", p.ServiceID, p.Name, p.ID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("service '%s' already has a probe named '%s'", p.ServiceID, p.Name)
+	}
+	if svc, err := m.Get(p.ServiceID); err == nil {
+		if svc.Virtual {
+			return fmt.Errorf("service '%s' is a virtual service", p.ServiceID)
+		}
+		for _, compID := range []string{p.Name, p.Name + "_latency"} {
+			if comp, ok := svc.Components[compID]; ok && comp.Source != probeSource(p.ID) {
+				return fmt.Errorf("service '%s' already has a component '%s'", p.ServiceID, compID)
+			}
+		}
+	}
+
+	if err := m.db.Conn.Save(p).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if moved != nil {
+		// After the save and under the lock, so a run in flight can't
+		// write the old components back
+		m.probeMu.Lock()
+		m.removeSourced(moved.ServiceID, probeSource(moved.ID))
+		m.probeMu.Unlock()
+	}
+	m.scheduleProbe(p.ID, 0)
+	return nil
+}
+
+// DeleteProbe removes a probe and its components
+func (m *Manager) DeleteProbe(id uint) error {
+	var p models.Probe
+	if err := m.db.Conn.First(&p, id).Error; err != nil {
+		return err
+	}
+	if err := m.db.Conn.Delete(&p).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	m.cancelProbe(id)
+	// Under the lock, so a run in flight can't write the components back
+	m.probeMu.Lock()
+	m.removeSourced(p.ServiceID, probeSource(id))
+	m.probeMu.Unlock()
+	return nil
+}
+
+// StartProbes schedules every probe, spread over its interval so they don't
+// all fire at once. With SYNAPSE_PROBE_URLS, service URLs are probed too.
+func (m *Manager) StartProbes() error {
+	if m.config.ProbeServiceURLs {
+		m.syncURLProbes()
+		ticker := time.NewTicker(time.Minute)
+		go func() {
+			for range ticker.C {
+				m.syncURLProbes()
+			}
+		}()
+	}
+
+	var probes []models.Probe
+	if err := m.db.Conn.Select("id", "interval").Find(&probes).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	for _, p := range probes {
+		m.scheduleProbe(p.ID, rand.N(time.Duration(p.Interval)*time.Second))
+	}
+	log.Printf("Scheduled %d probes", len(probes))
+	return nil
+}
+
+func (m *Manager) scheduleProbe(id uint, delay time.Duration) {
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+
+	if timer, ok := m.probeTimers[id]; ok {
+		timer.Stop()
+	}
+	m.probeTimers[id] = time.AfterFunc(delay, func() { m.runProbe(id) })
+}
+
+func (m *Manager) cancelProbe(id uint) {
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+
+	if timer, ok := m.probeTimers[id]; ok {
+		timer.Stop()
+		delete(m.probeTimers, id)
+	}
+}
+
+// runProbe runs a probe, writes its result into the service and re-arms it
+func (m *Manager) runProbe(id uint) {
+	var p models.Probe
+	result := m.db.Conn.Limit(1).Find(&p, id)
+	if result.Error != nil {
+		log.Printf("Failed to load probe %d: %v", id, result.Error)
+		m.scheduleProbe(id, time.Minute)
+		return
+	}
+	if result.RowsAffected == 0 {
+		m.cancelProbe(id)
+		return
+	}
+	defer m.scheduleProbe(id, time.Duration(p.Interval)*time.Second)
+
+	res := probe.Run(context.Background(), &p)
+	now := time.Now()
+	latency := float64(res.Latency.Microseconds()) / 1000
+	err := m.db.Conn.Model(&p).Select("last_run", "last_up", "last_latency", "last_error").Updates(map[string]any{
+		"last_run":     now,
+		"last_up":      res.Up,
+		"last_latency": latency,
+		"last_error":   res.Error,
+	}).Error
+	if err != nil {
+		log.Printf("Failed to store result of probe %d: %v", id, err)
+	}
+
+	status := models.Component{
+		Type:    "status_indicator",
+		Label:   p.Name,
+		Value:   "up",
+		Mapping: probeMapping,
+		Monitors: []models.Monitor{{
+			Condition: `value == "down"`,
+			Severity:  p.Severity,
+			Message:   fmt.Sprintf("Probe '%s' is down", p.Name),
+		}},
+	}
+	var latencyValue any = latency
+	if !res.Up {
+		status.Value = "down"
+		status.Monitors[0].Message = fmt.Sprintf("Probe '%s' is down: %s", p.Name, res.Error)
+		latencyValue = nil
+	}
+
+	// The probe may have been deleted or moved while it ran, its components
+	// are removed under the same lock
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+	var current models.Probe
+	result = m.db.Conn.Select("service_id", "name").Limit(1).Find(&current, id)
+	if result.Error != nil || result.RowsAffected == 0 || current.ServiceID != p.ServiceID || current.Name != p.Name {
+		return
+	}
+
+	ttl, message := m.probeOwnerState(p.ServiceID)
+	err = m.applySourced(sourcedUpdate{
+		ServiceID: p.ServiceID,
+		Source:    probeSource(p.ID),
+		Owner:     sourceProbe,
+		Section:   probeSection,
+		TTL:       ttl,
+		Message:   message,
+		Components: map[string]models.Component{
+			p.Name: status,
+			p.Name + "_latency": {
+				Type:  "stat",
+				Label: p.Name + " latency",
+				Unit:  "ms",
+				Value: latencyValue,
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to apply probe %d (%s/%s): %v", id, p.ServiceID, p.Name, err)
+	}
+}
+
+// probeOwnerState derives the TTL and message of a probe-created service
+// from all of its probes
+func (m *Manager) probeOwnerState(serviceID string) (int, string) {
+	probes, err := m.ListProbes(serviceID)
+	if err != nil {
+		log.Printf("Failed to load probes of %s: %v", serviceID, err)
+	}
+
+	ttl := 0
+	var failing []string
+	for _, p := range probes {
+		ttl = max(ttl, p.TTL())
+		if p.LastRun != nil && !p.LastUp {
+			failing = append(failing, fmt.Sprintf("%s: %s", p.Name, p.LastError))
+		}
+	}
+	return ttl, strings.Join(failing, "; ")
+}
+
+// syncURLProbes keeps one "url" HTTP probe per service URL
+func (m *Manager) syncURLProbes() {
+	var services []models.Service
+	err := m.db.Conn.Select("id", "url").
+		Where("url != '' AND archived = ? AND virtual = ?", false, false).
+		Find(&services).Error
+	if err != nil {
+		log.Printf("Failed to load service URLs: %v", err)
+		return
+	}
+	var probes []models.Probe
+	if err := m.db.Conn.Where("type = ?", models.ProbeHTTP).Find(&probes).Error; err != nil {
+		log.Printf("Failed to load URL probes: %v", err)
+		return
+	}
+
+	urls := make(map[string]string, len(services))
+	for _, svc := range services {
+		urls[svc.ID] = svc.URL
+	}
+	// A manual probe of the URL makes the automatic one unnecessary
+	probed := make(map[string]bool, len(probes))
+	for _, p := range probes {
+		if !p.Auto {
+			if p.Target == urls[p.ServiceID] {
+				probed[p.ServiceID] = true
+			}
+			continue
+		}
+		probed[p.ServiceID] = true
+		switch url, ok := urls[p.ServiceID]; {
+		case !ok:
+			if err := m.DeleteProbe(p.ID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Failed to remove URL probe of %s: %v", p.ServiceID, err)
+			}
+		case url != p.Target:
+			p.Target = url
+			if err := m.SaveProbe(&p); err != nil {
+				log.Printf("Failed to update URL probe of %s: %v", p.ServiceID, err)
+			}
+		}
+	}
+
+	for _, svc := range services {
+		if probed[svc.ID] {
+			continue
+		}
+		p := &models.Probe{ServiceID: svc.ID, Name: "url", Type: models.ProbeHTTP, Target: svc.URL, Auto: true}
+		if err := m.SaveProbe(p); err != nil {
+			log.Printf("Skipping URL probe of %s: %v", svc.ID, err)
+		}
+	}
+}
+
+// probeSource is the Component.Source of a probe's components
+func probeSource(id uint) string {
+	return fmt.Sprintf("probe:%d", id)
+}
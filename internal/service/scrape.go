@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/scrape"
+)
+
+// sourceScrape marks services registered by a scrape target
+const sourceScrape = "scrape"
+
+// scrapeSection is the layout section scraped components are listed under
+const scrapeSection = "Metrics"
+
+// ListScrapeTargets returns all scrape targets
+func (m *Manager) ListScrapeTargets() ([]models.ScrapeTarget, error) {
+	var targets []models.ScrapeTarget
+	if err := m.db.Conn.Order("service_id").Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// SaveScrapeTarget creates or updates a scrape target and scrapes it right away
+func (m *Manager) SaveScrapeTarget(t *models.ScrapeTarget) error {
+	t.Defaults()
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	for i, metric := range t.Metrics {
+		if _, err := scrape.ParseSelector(metric.Selector); err != nil {
+			return fmt.Errorf("metrics[%d]: %w", i, err)
+		}
+	}
+
+	if t.ID != 0 {
+		var existing models.ScrapeTarget
+		if err := m.db.Conn.First(&existing, t.ID).Error; err != nil {
+			return err
+		}
+		t.CreatedAt = existing.CreatedAt
+		t.LastScrape, t.LastError = existing.LastScrape, existing.LastError
+	}
+
+	var count int64
+	err := m.db.Conn.Model(&models.ScrapeTarget{}).
+		Where("service_id = ? AND id != ?", t.ServiceID, t.ID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("service '%s' already has a scrape target", t.ServiceID)
+	}
+	if svc, err := m.Get(t.ServiceID); err == nil && svc.Source != sourceScrape {
+		return fmt.Errorf("service '%s' is already registered by another source", t.ServiceID)
+	}
+
+	if err := m.db.Conn.Save(t).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	m.scheduleScrape(t.ID, 0)
+	return nil
+}
+
+// DeleteScrapeTarget stops scraping a target. Its service is left to expire
+// through its TTL, like a service whose axon went away.
+func (m *Manager) DeleteScrapeTarget(id uint) error {
+	var t models.ScrapeTarget
+	if err := m.db.Conn.First(&t, id).Error; err != nil {
+		return err
+	}
+	if err := m.db.Conn.Delete(&t).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	m.cancelScrape(id)
+	return nil
+}
+
+// StartScrapes schedules every scrape target, spread over its interval
+func (m *Manager) StartScrapes() error {
+	var targets []models.ScrapeTarget
+	if err := m.db.Conn.Select("id", "interval").Find(&targets).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	for _, t := range targets {
+		m.scheduleScrape(t.ID, rand.N(time.Duration(t.Interval)*time.Second))
+	}
+	log.Printf("Scheduled %d scrape targets", len(targets))
+	return nil
+}
+
+func (m *Manager) scheduleScrape(id uint, delay time.Duration) {
+	m.scrapeMu.Lock()
+	defer m.scrapeMu.Unlock()
+
+	if timer, ok := m.scrapeTimers[id]; ok {
+		timer.Stop()
+	}
+	m.scrapeTimers[id] = time.AfterFunc(delay, func() { m.runScrape(id) })
+}
+
+func (m *Manager) cancelScrape(id uint) {
+	m.scrapeMu.Lock()
+	defer m.scrapeMu.Unlock()
+
+	if timer, ok := m.scrapeTimers[id]; ok {
+		timer.Stop()
+		delete(m.scrapeTimers, id)
+	}
+}
+
+// runScrape scrapes a target, registers its service and re-arms it. A failed
+// scrape only records the error: the service goes offline through its TTL.
+func (m *Manager) runScrape(id uint) {
+	var t models.ScrapeTarget
+	result := m.db.Conn.Limit(1).Find(&t, id)
+	if result.Error != nil {
+		log.Printf("Failed to load scrape target %d: %v", id, result.Error)
+		m.scheduleScrape(id, time.Minute)
+		return
+	}
+	if result.RowsAffected == 0 {
+		m.cancelScrape(id)
+		return
+	}
+	defer m.scheduleScrape(id, time.Duration(t.Interval)*time.Second)
+
+	err := m.scrapeInto(&t)
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+		log.Printf("Scrape of %s (%s) failed: %v", t.ServiceID, t.URL, err)
+	}
+	err = m.db.Conn.Model(&t).Select("last_scrape", "last_error").Updates(map[string]any{
+		"last_scrape": time.Now(),
+		"last_error":  lastError,
+	}).Error
+	if err != nil {
+		log.Printf("Failed to store result of scrape target %d: %v", id, err)
+	}
+}
+
+// scrapeInto fetches the target and registers its values through the
+// discovery path
+func (m *Manager) scrapeInto(t *models.ScrapeTarget) error {
+	if svc, err := m.Get(t.ServiceID); err == nil && svc.Source != sourceScrape {
+		return fmt.Errorf("service '%s' is registered by another source", t.ServiceID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(t.Timeout)*time.Second)
+	defer cancel()
+	samples, err := scrape.Fetch(ctx, t.URL)
+	if err != nil {
+		return err
+	}
+
+	p := models.ServicePayload{
+		Service: models.Service{
+			ID:          t.ServiceID,
+			Name:        t.Name,
+			Group:       t.Group,
+			Description: t.Description,
+			URL:         t.URL,
+			TTL:         t.TTL(),
+			Source:      sourceScrape,
+			Components:  make(map[string]models.Component, len(t.Metrics)),
+			Layout:      models.LayoutSchema{Type: "sections"},
+		},
+	}
+	ids := make([]string, 0, len(t.Metrics))
+	for _, metric := range t.Metrics {
+		sel, err := scrape.ParseSelector(metric.Selector)
+		if err != nil {
+			return err
+		}
+		// A selector matching nothing leaves the component empty
+		var value any
+		if values := sel.Select(samples); len(values) > 0 {
+			value = models.AggregateNumbers(metric.Aggregate, values)
+		}
+		p.Components[metric.Component] = models.Component{
+			Type:     metric.Type,
+			Label:    metric.Label,
+			Unit:     metric.Unit,
+			Min:      metric.Min,
+			Max:      metric.Max,
+			Value:    value,
+			Monitors: metric.Monitors,
+		}
+		ids = append(ids, metric.Component)
+	}
+	addToSection(&p.Layout, scrapeSection, ids)
+
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return m.register("", &p, payload)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// recordSnapshot stores the registration payload as a new version if its
+// definition differs from the latest snapshot
+func (m *Manager) recordSnapshot(serviceID string, payload []byte) {
+	raw, hash, err := snapshotPayload(payload)
+	if err != nil {
+		log.Printf("Failed to snapshot payload of %s: %v", serviceID, err)
+		return
+	}
+
+	var latest models.ServiceSnapshot
+	result := m.db.Conn.Where("service_id = ?", serviceID).Order("version DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		log.Printf("Failed to load latest snapshot of %s: %v", serviceID, result.Error)
+		return
+	}
+	if result.RowsAffected > 0 && latest.Hash == hash {
+		return
+	}
+
+	snap := models.ServiceSnapshot{
+		ServiceID: serviceID,
+		Version:   latest.Version + 1,
+		Hash:      hash,
+		Payload:   raw,
+	}
+	if err := m.db.Conn.Create(&snap).Error; err != nil {
+		log.Printf("Failed to store snapshot of %s: %v", serviceID, err)
+		return
+	}
+	log.Printf("Stored definition snapshot v%d of %s", snap.Version, serviceID)
+}
+
+// snapshotPayload strips the auth token from a payload and hashes its
+// definition, i.e. everything except runtime values. The TTL counts as
+// runtime: ping checks shorten it to the grace time while a run is going.
+func snapshotPayload(payload []byte) (string, string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(payload, &doc); err != nil {
+		return "", "", err
+	}
+	delete(doc, "auth_token")
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Re-decode for the definition so the stored copy keeps its values
+	var def map[string]any
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return "", "", err
+	}
+	for _, key := range []string{"status", "message", "last_seen", "instance", "ttl"} {
+		delete(def, key)
+	}
+	if comps, ok := def["components"].(map[string]any); ok {
+		for _, c := range comps {
+			if comp, ok := c.(map[string]any); ok {
+				delete(comp, "value")
+			}
+		}
+	}
+
+	// encoding/json sorts map keys, so this is canonical
+	canonical, err := json.Marshal(def)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return string(raw), hex.EncodeToString(sum[:]), nil
+}
+
+// ListSnapshots returns all stored definition versions of a service, newest first
+func (m *Manager) ListSnapshots(serviceID string) ([]models.ServiceSnapshot, error) {
+	var snaps []models.ServiceSnapshot
+	err := m.db.Conn.Where("service_id = ?", serviceID).Order("version DESC").Find(&snaps).Error
+	return snaps, err
+}
+
+// SnapshotService decodes a stored snapshot back into a service definition.
+// Version 0 selects the latest snapshot.
+func (m *Manager) SnapshotService(serviceID string, version int) (*models.Service, int, error) {
+	query := m.db.Conn.Where("service_id = ?", serviceID)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	var snap models.ServiceSnapshot
+	result := query.Order("version DESC").Limit(1).Find(&snap)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, 0, fmt.Errorf("no snapshot found for service '%s'", serviceID)
+	}
+
+	var p models.ServicePayload
+	if err := json.Unmarshal([]byte(snap.Payload), &p); err != nil {
+		return nil, 0, fmt.Errorf("corrupt snapshot v%d: %w", snap.Version, err)
+	}
+	return &p.Service, snap.Version, nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// sourcedUpdate is a batch of components written into a service by Core
+// itself (probes, ...) rather than by its axon. The components are kept
+// when the axon registers again.
+type sourcedUpdate struct {
+	ServiceID  string
+	Source     string // Component.Source of the batch, e.g. "probe:3"
+	Owner      string // Service.Source if the batch has to create the service
+	Section    string // Layout section the components are listed under
+	TTL        int    // Heartbeat TTL of an owned service
+	Message    string // Message of an owned service
+	Components map[string]models.Component
+}
+
+// applySourced writes a batch of sourced components into a service. A service
+// created by the batch's owner counts the update as a heartbeat; a service
+// registered by an axon only gets its components and status refreshed.
+func (m *Manager) applySourced(u sourcedUpdate) error {
+	m.sourceMu.Lock()
+	defer m.sourceMu.Unlock()
+
+	existing, err := m.Get(u.ServiceID)
+	if err != nil {
+		existing = nil
+	}
+	if existing != nil && existing.Virtual {
+		return fmt.Errorf("service '%s' is a virtual service", u.ServiceID)
+	}
+
+	now := time.Now()
+	var svc models.Service
+	if existing != nil {
+		svc = *existing
+		svc.Components = make(map[string]models.Component, len(existing.Components)+len(u.Components))
+		for compID, comp := range existing.Components {
+			svc.Components[compID] = comp
+		}
+		svc.Layout.Root = slices.Clone(existing.Layout.Root)
+	} else {
+		svc = models.Service{
+			ID:         u.ServiceID,
+			Name:       u.ServiceID,
+			Source:     u.Owner,
+			Components: make(map[string]models.Component, len(u.Components)),
+			Layout:     models.LayoutSchema{Type: "sections"},
+		}
+	}
+
+	// Replace the batch's previous components
+	var ids []string
+	for compID, comp := range svc.Components {
+		if comp.Source == u.Source {
+			delete(svc.Components, compID)
+		}
+	}
+	for compID, comp := range u.Components {
+		comp.ID = compID
+		comp.Source = u.Source
+		svc.Components[compID] = comp
+		ids = append(ids, compID)
+	}
+	sort.Strings(ids)
+	pruneLayout(&svc)
+	addToSection(&svc.Layout, u.Section, ids)
+
+	owned := svc.Source != "" && svc.Source == u.Owner
+	if owned {
+		svc.ReportedStatus = "online"
+		svc.Message = u.Message
+		svc.TTL = u.TTL
+		svc.LastSeen = now
+		svc.Archived = false
+		svc.ArchivedAt = nil
+	}
+
+	var prevFiring []models.FiringMonitor
+	if existing != nil {
+		prevFiring = existing.Firing
+	}
+	m.evaluateMonitors(&svc, prevFiring)
+	svc.Status = m.baseStatus(&svc, now)
+	m.applyImpact(&svc)
+
+	if existing == nil {
+		if err := m.db.Conn.Create(&svc).Error; err != nil {
+			return fmt.Errorf("db error: %w", err)
+		}
+		m.recordEvent(models.Event{
+			ServiceID: svc.ID,
+			Type:      models.EventRegistered,
+			Status:    svc.Status,
+			Message:   fmt.Sprintf("Service '%s' created by %s", svc.Name, u.Owner),
+		})
+	} else {
+		err := m.db.Conn.Model(&svc).
+			Select("layout", "components", "status", "reported_status", "firing", "impacted_by", "issues", "message", "ttl", "last_seen", "archived", "archived_at").
+			Updates(&svc).Error
+		if err != nil {
+			return fmt.Errorf("db error: %w", err)
+		}
+		m.recordStatusChange(&svc, existing.Status, statusReason(&svc))
+		if owned {
+			m.recordUnarchive(existing)
+		}
+	}
+
+	if owned {
+		m.heartbeat(&svc)
+	}
+	m.recordHistory(&models.Service{ID: svc.ID, LastSeen: now, Components: u.Components})
+	if existing == nil || existing.Status == svc.Status {
+		m.refreshVirtual(svc.ID)
+	}
+	return nil
+}
+
+// removeSourced drops the components of a source from a service, e.g. after
+// its probe was deleted
+func (m *Manager) removeSourced(serviceID, source string) {
+	m.sourceMu.Lock()
+	defer m.sourceMu.Unlock()
+
+	svc, err := m.Get(serviceID)
+	if err != nil {
+		return
+	}
+
+	removed := false
+	for compID, comp := range svc.Components {
+		if comp.Source == source {
+			delete(svc.Components, compID)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	pruneLayout(svc)
+
+	prevStatus := svc.Status
+	m.evaluateMonitors(svc, svc.Firing)
+	svc.Status = m.baseStatus(svc, time.Now())
+	m.applyImpact(svc)
+
+	err = m.db.Conn.Model(svc).
+		Select("layout", "components", "status", "firing", "impacted_by", "issues").
+		Updates(svc).Error
+	if err != nil {
+		log.Printf("Failed to remove %s components from %s: %v", source, serviceID, err)
+		return
+	}
+	m.recordStatusChange(svc, prevStatus, statusReason(svc))
+}
+
+// keepSourced carries the sourced components of the stored service over into
+// an axon registration, which only knows its own components
+func keepSourced(existing, svc *models.Service) {
+	for _, section := range existing.Layout.Root {
+		var ids []string
+		for _, compID := range section.Children {
+			comp, ok := existing.Components[compID]
+			if !ok || comp.Source == "" {
+				continue
+			}
+			if _, taken := svc.Components[compID]; taken {
+				continue
+			}
+			if svc.Components == nil {
+				svc.Components = make(map[string]models.Component)
+			}
+			svc.Components[compID] = comp
+			ids = append(ids, compID)
+		}
+		addToSection(&svc.Layout, section.Title, ids)
+	}
+}
+
+// addToSection appends component IDs to the layout section with the given
+// title, creating it if needed
+func addToSection(layout *models.LayoutSchema, title string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if layout.Type == "" {
+		layout.Type = "sections"
+	}
+	for i := range layout.Root {
+		if layout.Root[i].Title != title {
+			continue
+		}
+		children := slices.Clone(layout.Root[i].Children)
+		for _, id := range ids {
+			if !slices.Contains(children, id) {
+				children = append(children, id)
+			}
+		}
+		layout.Root[i].Children = children
+		return
+	}
+	layout.Root = append(layout.Root, models.LayoutSection{Type: "section", Title: title, Children: ids})
+}
+
+// pruneLayout drops layout references to removed components and the
+// sections left empty by that
+func pruneLayout(svc *models.Service) {
+	var root []models.LayoutSection
+	for _, section := range svc.Layout.Root {
+		hadChildren := len(section.Children) > 0
+		section.Children = slices.DeleteFunc(slices.Clone(section.Children), func(id string) bool {
+			_, ok := svc.Components[id]
+			return !ok
+		})
+		if hadChildren && len(section.Children) == 0 {
+			continue
+		}
+		root = append(root, section)
+	}
+	svc.Layout.Root = root
+}
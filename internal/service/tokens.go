@@ -0,0 +1,158 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+// tokenPrefix marks per-axon tokens so they are easy to spot in configs
+const tokenPrefix = "syn_"
+
+// ListTokens returns all axon tokens, including revoked ones. Secrets are
+// never included.
+func (m *Manager) ListTokens() ([]models.AxonToken, error) {
+	var tokens []models.AxonToken
+	if err := m.db.Conn.Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateToken issues a new axon token. The plain secret is set on t.Token and
+// cannot be retrieved again.
+func (m *Manager) CreateToken(t *models.AxonToken) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return err
+	}
+	t.ID = 0
+	t.Hash = hashToken(secret)
+	t.Prefix = secret[:len(tokenPrefix)+6]
+	t.RevokedAt = nil
+	t.LastUsedAt = nil
+	if err := m.db.Conn.Create(t).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	t.Token = secret
+	return nil
+}
+
+// RotateToken issues a replacement for a token with the same name, pattern
+// and expiry. The old token keeps working for the grace period, so axons can
+// be reconfigured without downtime.
+func (m *Manager) RotateToken(id uint, grace time.Duration) (*models.AxonToken, error) {
+	var old models.AxonToken
+	if err := m.db.Conn.First(&old, id).Error; err != nil {
+		return nil, err
+	}
+	if old.RevokedAt != nil {
+		return nil, fmt.Errorf("token %d is revoked", id)
+	}
+
+	replacement := &models.AxonToken{
+		Name:      old.Name,
+		Pattern:   old.Pattern,
+		ExpiresAt: old.ExpiresAt,
+	}
+	if replacement.Expired(time.Now()) {
+		replacement.ExpiresAt = nil
+	}
+	if err := m.CreateToken(replacement); err != nil {
+		return nil, err
+	}
+
+	retire := time.Now().Add(grace)
+	if old.ExpiresAt == nil || retire.Before(*old.ExpiresAt) {
+		if err := m.db.Conn.Model(&old).Update("expires_at", retire).Error; err != nil {
+			return nil, fmt.Errorf("db error: %w", err)
+		}
+	}
+	return replacement, nil
+}
+
+// RevokeToken disables a token immediately. The record is kept for auditing.
+func (m *Manager) RevokeToken(id uint) error {
+	result := m.db.Conn.Model(&models.AxonToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// authorize checks that token may write serviceID. The global AuthToken is
+// accepted for every service unless the legacy mode is disabled.
+func (m *Manager) authorize(serviceID, token string) error {
+	if token == "" {
+		return fmt.Errorf("auth_token is required")
+	}
+	if m.config.AllowGlobalToken && m.config.AuthToken != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(m.config.AuthToken)) == 1 {
+		return nil
+	}
+
+	var t models.AxonToken
+	result := m.db.Conn.Where("hash = ?", hashToken(token)).Limit(1).Find(&t)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("invalid auth_token")
+	}
+
+	now := time.Now()
+	switch {
+	case t.RevokedAt != nil:
+		return fmt.Errorf("auth_token has been revoked")
+	case t.Expired(now):
+		return fmt.Errorf("auth_token expired at %s", t.ExpiresAt.Format(time.RFC3339))
+	case !t.Allows(serviceID):
+		return fmt.Errorf("auth_token is not valid for service '%s'", serviceID)
+	}
+
+	// Heartbeats are frequent, only touch last_used_at once a minute
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > time.Minute {
+		if err := m.db.Conn.Model(&t).Update("last_used_at", now).Error; err != nil {
+			log.Printf("Failed to update token %d usage: %v", t.ID, err)
+		}
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	secret, err := randomHex(24)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return tokenPrefix + secret, nil
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
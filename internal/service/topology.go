@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// topologyColumns are the columns needed to recompute a service's impact
+var topologyColumns = []string{"id", "name", "group", "tags", "status", "reported_status", "firing", "ttl", "last_seen", "depends_on", "impacted_by", "archived"}
+
+// Topology returns the dependency graph of all non-archived services
+func (m *Manager) Topology() (*models.Topology, error) {
+	var services []models.Service
+	err := m.db.Conn.Select("id", "name", "group", "status", "depends_on", "impacted_by").
+		Where("archived = ?", false).Order("id").Find(&services).Error
+	if err != nil {
+		return nil, err
+	}
+
+	topo := &models.Topology{Nodes: []models.TopologyNode{}, Edges: []models.TopologyEdge{}}
+	known := make(map[string]bool, len(services))
+	for _, svc := range services {
+		known[svc.ID] = true
+		topo.Nodes = append(topo.Nodes, models.TopologyNode{
+			ID:         svc.ID,
+			Name:       svc.Name,
+			Group:      svc.Group,
+			Status:     svc.Status,
+			ImpactedBy: svc.ImpactedBy,
+		})
+	}
+
+	missing := make(map[string]bool)
+	for _, svc := range services {
+		for _, up := range svc.DependsOn {
+			topo.Edges = append(topo.Edges, models.TopologyEdge{From: svc.ID, To: up})
+			if !known[up] && !missing[up] {
+				missing[up] = true
+				topo.Nodes = append(topo.Nodes, models.TopologyNode{ID: up, Missing: true})
+			}
+		}
+	}
+	return topo, nil
+}
+
+// baseStatus is the effective status of a service before impact propagation
+func (m *Manager) baseStatus(svc *models.Service, now time.Time) string {
+	if svc.TTL > 0 && now.Sub(svc.LastSeen) >= time.Duration(svc.TTL)*time.Second {
+		if m.activeMaintenance(svc) != nil {
+			return "maintenance"
+		}
+		return "offline"
+	}
+	return models.EffectiveStatus(svc.ReportedStatus, svc.Firing)
+}
+
+// applyImpact marks a failing service as "impacted" when one of its upstream
+// services is failing too, and records the root causes in ImpactedBy
+func (m *Manager) applyImpact(svc *models.Service) {
+	svc.ImpactedBy = nil
+	if len(svc.DependsOn) == 0 || (svc.Status != "offline" && svc.Status != "error") {
+		return
+	}
+
+	var upstream []models.Service
+	err := m.db.Conn.Select("id", "status", "impacted_by").
+		Where("id IN ? AND archived = ?", svc.DependsOn, false).
+		Find(&upstream).Error
+	if err != nil {
+		log.Printf("Failed to load upstream of %s: %v", svc.ID, err)
+		return
+	}
+
+	var roots []string
+	for _, up := range upstream {
+		switch up.Status {
+		case "offline", "error":
+			roots = append(roots, up.ID)
+		case "impacted":
+			roots = append(roots, up.ImpactedBy...)
+		}
+	}
+	// In a cycle a service can end up as its own root cause
+	roots = slices.DeleteFunc(roots, func(id string) bool { return id == svc.ID })
+	if len(roots) == 0 {
+		return
+	}
+	sort.Strings(roots)
+	svc.Status = "impacted"
+	svc.ImpactedBy = slices.Compact(roots)
+}
+
+// propagateImpact re-evaluates the direct dependents of a service after its
+// status changed. Changes cascade through recordStatusChange.
+func (m *Manager) propagateImpact(id string) {
+	var candidates []models.Service
+	err := m.db.Conn.Select(topologyColumns).
+		Where(`archived = ? AND depends_on LIKE ? ESCAPE '\'`, false, jsonStringPattern(id)).
+		Find(&candidates).Error
+	if err != nil {
+		log.Printf("Failed to load dependents of %s: %v", id, err)
+		return
+	}
+
+	now := time.Now()
+	for i := range candidates {
+		dep := &candidates[i]
+		if !slices.Contains(dep.DependsOn, id) || dep.ID == id {
+			continue
+		}
+
+		prevStatus, prevRoots := dep.Status, dep.ImpactedBy
+		dep.Status = m.baseStatus(dep, now)
+		m.applyImpact(dep)
+		if dep.Status == prevStatus && slices.Equal(dep.ImpactedBy, prevRoots) {
+			continue
+		}
+
+		result := m.db.Conn.Model(dep).
+			Where("status = ?", prevStatus).
+			Select("status", "impacted_by").
+			Updates(dep)
+		if result.Error != nil {
+			log.Printf("Failed to update impact of %s: %v", dep.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			// Reported in the meantime
+			continue
+		}
+
+		message := fmt.Sprintf("Upstream '%s' recovered", id)
+		if dep.Status == "impacted" {
+			message = fmt.Sprintf("Impacted by upstream '%s'", strings.Join(dep.ImpactedBy, "', '"))
+		}
+		m.recordStatusChange(dep, prevStatus, message)
+	}
+}
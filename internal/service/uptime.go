@@ -0,0 +1,283 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// uptimeListWindow is the window of the uptime summary in the service list
+	uptimeListWindow = "30d"
+	// uptimeCacheTTL bounds how often the list recomputes a service's uptime
+	uptimeCacheTTL = 5 * time.Minute
+)
+
+type cachedUptime struct {
+	percent  float64
+	computed time.Time
+}
+
+// uptimeTally accumulates availability over one or more services
+type uptimeTally struct {
+	monitored     float64
+	downtime      float64
+	outages       int
+	resolved      int
+	resolvedTotal float64
+	longest       float64
+}
+
+func (t *uptimeTally) add(o uptimeTally) {
+	t.monitored += o.monitored
+	t.downtime += o.downtime
+	t.outages += o.outages
+	t.resolved += o.resolved
+	t.resolvedTotal += o.resolvedTotal
+	t.longest = math.Max(t.longest, o.longest)
+}
+
+func (t *uptimeTally) fill(u *models.Uptime) {
+	u.UptimePercent = 100
+	if t.monitored > 0 {
+		u.UptimePercent = round3((t.monitored - t.downtime) / t.monitored * 100)
+	}
+	u.Monitored = round3(t.monitored)
+	u.Downtime = round3(t.downtime)
+	u.Outages = t.outages
+	if t.resolved > 0 {
+		u.MTTR = round3(t.resolvedTotal / float64(t.resolved))
+	}
+	u.LongestOutage = round3(t.longest)
+}
+
+// ParseWindow parses an uptime window such as "24h", "7d" or "30d"
+func ParseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid window '%s' (use e.g. 24h, 7d, 30d)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window '%s' (use e.g. 24h, 7d, 30d)", s)
+	}
+	return d, nil
+}
+
+// Uptime reports the availability of a service over the last window,
+// reconstructed from its status_changed events
+func (m *Manager) Uptime(id, window string, excludeMaintenance bool) (*models.Uptime, error) {
+	d, err := ParseWindow(window)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	u := &models.Uptime{
+		ServiceID:           id,
+		Window:              window,
+		From:                to.Add(-d),
+		To:                  to,
+		ExcludesMaintenance: excludeMaintenance,
+	}
+	t, err := m.tallyUptime(svc, u.From, to, excludeMaintenance, m.loadMaintenance())
+	if err != nil {
+		return nil, err
+	}
+	t.fill(u)
+	return u, nil
+}
+
+// GroupUptime reports the combined availability of the services in a group.
+// The percentage is weighted by monitored time.
+func (m *Manager) GroupUptime(group, window string, excludeMaintenance bool) (*models.Uptime, error) {
+	d, err := ParseWindow(window)
+	if err != nil {
+		return nil, err
+	}
+
+	var services []models.Service
+	if err := m.db.Conn.Where(`"group" = ? AND archived = ?`, group, false).Order("id").Find(&services).Error; err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	to := time.Now()
+	u := &models.Uptime{
+		Group:               group,
+		Window:              window,
+		From:                to.Add(-d),
+		To:                  to,
+		ExcludesMaintenance: excludeMaintenance,
+	}
+	windows := m.loadMaintenance()
+	var total uptimeTally
+	for i := range services {
+		t, err := m.tallyUptime(&services[i], u.From, to, excludeMaintenance, windows)
+		if err != nil {
+			return nil, err
+		}
+		total.add(t)
+
+		su := models.Uptime{ServiceID: services[i].ID, Window: window, From: u.From, To: to, ExcludesMaintenance: excludeMaintenance}
+		t.fill(&su)
+		u.Services = append(u.Services, su)
+	}
+	total.fill(u)
+	return u, nil
+}
+
+// tallyUptime walks the status timeline of a service between from and to.
+// With excludeMaintenance, maintenance windows and the "maintenance" status
+// are left out of both monitored time and downtime.
+func (m *Manager) tallyUptime(svc *models.Service, from, to time.Time, excludeMaintenance bool, windows []models.MaintenanceWindow) (uptimeTally, error) {
+	var t uptimeTally
+
+	start := from
+	if svc.CreatedAt.After(start) {
+		start = svc.CreatedAt
+	}
+	if !to.After(start) {
+		return t, nil
+	}
+
+	// Status at the start of the window
+	var before models.Event
+	result := m.db.Conn.
+		Where("service_id = ? AND type = ? AND created_at <= ?", svc.ID, models.EventStatusChanged, start.UTC()).
+		Order("created_at DESC, id DESC").Limit(1).Find(&before)
+	if result.Error != nil {
+		return t, result.Error
+	}
+	var events []models.Event
+	err := m.db.Conn.
+		Where("service_id = ? AND type = ? AND created_at > ? AND created_at < ?", svc.ID, models.EventStatusChanged, start.UTC(), to.UTC()).
+		Order("created_at, id").Find(&events).Error
+	if err != nil {
+		return t, err
+	}
+
+	status := svc.Status
+	switch {
+	case result.RowsAffected > 0:
+		status = before.Status
+	case len(events) > 0:
+		status = events[0].PrevStatus
+	}
+
+	type change struct {
+		at     time.Time
+		status string
+	}
+	changes := []change{{start, status}}
+	for _, e := range events {
+		changes = append(changes, change{e.CreatedAt, e.Status})
+	}
+
+	var excluded []models.Interval
+	if excludeMaintenance {
+		for i := range windows {
+			if windows[i].Matches(svc) {
+				excluded = append(excluded, windows[i].Occurrences(start, to)...)
+			}
+		}
+	}
+
+	// Split the window at every status change and exclusion boundary
+	points := []time.Time{start, to}
+	for _, c := range changes {
+		points = append(points, c.at)
+	}
+	for _, iv := range excluded {
+		points = append(points, iv.Start, iv.End)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Before(points[j]) })
+
+	inOutage := false
+	current := 0.0
+	next := 0
+	for i := 0; i+1 < len(points); i++ {
+		p, q := points[i], points[i+1]
+		if p.Before(start) || !q.After(p) || q.After(to) {
+			continue
+		}
+		for next < len(changes) && !changes[next].at.After(p) {
+			status = changes[next].status
+			next++
+		}
+
+		skip := excludeMaintenance && status == "maintenance"
+		for _, iv := range excluded {
+			if !p.Before(iv.Start) && p.Before(iv.End) {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+
+		length := q.Sub(p).Seconds()
+		t.monitored += length
+		if status == "offline" || status == "maintenance" {
+			t.downtime += length
+			if !inOutage {
+				inOutage = true
+				current = 0
+				t.outages++
+			}
+			current += length
+			t.longest = math.Max(t.longest, current)
+		} else if inOutage {
+			inOutage = false
+			t.resolved++
+			t.resolvedTotal += current
+		}
+	}
+	return t, nil
+}
+
+// decorateUptime attaches the cached uptime summary to listed services
+func (m *Manager) decorateUptime(services ...*models.Service) {
+	windows := m.loadMaintenance()
+	d, _ := ParseWindow(uptimeListWindow)
+	now := time.Now()
+
+	m.uptimeMu.Lock()
+	defer m.uptimeMu.Unlock()
+	for _, svc := range services {
+		cached, ok := m.uptimeCache[svc.ID]
+		if !ok || now.Sub(cached.computed) > uptimeCacheTTL {
+			t, err := m.tallyUptime(svc, now.Add(-d), now, true, windows)
+			if err != nil {
+				log.Printf("Failed to compute uptime of %s: %v", svc.ID, err)
+				continue
+			}
+			var u models.Uptime
+			t.fill(&u)
+			cached = cachedUptime{percent: u.UptimePercent, computed: now}
+			m.uptimeCache[svc.ID] = cached
+		}
+		svc.Uptime = &models.UptimeSummary{Window: uptimeListWindow, Percent: cached.percent}
+	}
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
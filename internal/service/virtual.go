@@ -0,0 +1,251 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+	"gorm.io/gorm/clause"
+)
+
+// ListVirtual returns all virtual service definitions
+func (m *Manager) ListVirtual() ([]models.VirtualService, error) {
+	var defs []models.VirtualService
+	if err := m.db.Conn.Order("id").Find(&defs).Error; err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// GetVirtual returns a single virtual service definition
+func (m *Manager) GetVirtual(id string) (*models.VirtualService, error) {
+	var def models.VirtualService
+	if err := m.db.Conn.First(&def, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// SaveVirtual creates or replaces a virtual service definition and evaluates
+// it right away. Invalid definitions return a *validation.Error.
+func (m *Manager) SaveVirtual(def *models.VirtualService) error {
+	report := &validation.Report{}
+	if def.ID == "" {
+		report.Errorf("id", "missing_field", "service id is required")
+		return &validation.Error{Report: report}
+	}
+	validation.CheckVirtual(def, report)
+	if report.HasErrors() {
+		return &validation.Error{ServiceID: def.ID, Report: report}
+	}
+
+	var inputs []string
+	for _, comp := range def.Components {
+		if comp.Expr == "" {
+			continue
+		}
+		ids, _ := evaluator.Inputs(comp.Expr)
+		inputs = append(inputs, ids...)
+	}
+	slices.Sort(inputs)
+	def.Inputs = slices.Compact(inputs)
+
+	// The ID must not be taken by an axon
+	var existing models.Service
+	result := m.db.Conn.Select("id", "virtual").Where("id = ?", def.ID).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected > 0 && !existing.Virtual {
+		report.Errorf("id", "id_taken", "service '%s' is registered by an axon", def.ID)
+		return &validation.Error{ServiceID: def.ID, Report: report}
+	}
+
+	// Virtual services may read each other, but not in a cycle
+	if cycle := m.virtualCycle(def); cycle != nil {
+		report.Errorf("components", "cycle", "virtual services read each other in a cycle: %v", cycle)
+		return &validation.Error{ServiceID: def.ID, Report: report}
+	}
+
+	var prev models.VirtualService
+	if err := m.db.Conn.Select("created_at").Where("id = ?", def.ID).Limit(1).Find(&prev).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	def.CreatedAt = prev.CreatedAt
+	if err := m.db.Conn.Save(def).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+
+	m.evaluateVirtual(def)
+	return nil
+}
+
+// DeleteVirtual removes a virtual service definition and its service
+func (m *Manager) DeleteVirtual(id string) error {
+	if _, err := m.GetVirtual(id); err != nil {
+		return err
+	}
+	return m.Delete(id)
+}
+
+// virtualCycle returns the path of a cycle through def, if saving it would
+// create one
+func (m *Manager) virtualCycle(def *models.VirtualService) []string {
+	var defs []models.VirtualService
+	if err := m.db.Conn.Select("id", "inputs").Find(&defs).Error; err != nil {
+		log.Printf("Failed to load virtual services: %v", err)
+		return nil
+	}
+	graph := make(map[string][]string, len(defs)+1)
+	for _, d := range defs {
+		graph[d.ID] = d.Inputs
+	}
+	graph[def.ID] = def.Inputs
+
+	var visit func(id string, path []string) []string
+	visit = func(id string, path []string) []string {
+		for _, input := range graph[id] {
+			if input == def.ID {
+				return append(path, input)
+			}
+			if slices.Contains(path, input) {
+				continue
+			}
+			if cycle := visit(input, append(path, input)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(def.ID, []string{def.ID})
+}
+
+// refreshVirtual re-evaluates the virtual services reading a service after
+// its values or status changed
+func (m *Manager) refreshVirtual(id string) {
+	var defs []models.VirtualService
+	err := m.db.Conn.Where(`inputs LIKE ? ESCAPE '\'`, jsonStringPattern(id)).Find(&defs).Error
+	if err != nil {
+		log.Printf("Failed to load virtual services reading %s: %v", id, err)
+		return
+	}
+	for i := range defs {
+		if slices.Contains(defs[i].Inputs, id) {
+			m.evaluateVirtual(&defs[i])
+		}
+	}
+}
+
+// evaluateVirtual computes the components of a virtual service, runs its
+// monitors and stores the result as a regular service
+func (m *Manager) evaluateVirtual(def *models.VirtualService) {
+	existing, err := m.Get(def.ID)
+	if err != nil {
+		existing = nil
+	}
+	if existing != nil && existing.Archived {
+		return
+	}
+
+	svc := def.Service()
+	svc.ReportedStatus = "online"
+	svc.LastSeen = time.Now()
+
+	env := m.virtualEnv(def.Inputs)
+	for compID, vc := range def.Components {
+		if vc.Expr == "" {
+			continue
+		}
+		comp := svc.Components[compID]
+		value, err := evaluator.Compute(vc.Expr, env)
+		if err != nil {
+			// Typically an input that is not registered (yet)
+			svc.Issues = append(svc.Issues, models.ValidationIssue{
+				Path:     "components." + compID + ".expr",
+				Severity: validation.SeverityWarning,
+				Code:     "expr_error",
+				Message:  err.Error(),
+			})
+		}
+		comp.Value = value
+		svc.Components[compID] = comp
+	}
+	sort.Slice(svc.Issues, func(i, j int) bool { return svc.Issues[i].Path < svc.Issues[j].Path })
+
+	var prevFiring []models.FiringMonitor
+	if existing != nil {
+		prevFiring = existing.Firing
+	}
+	m.evaluateMonitors(svc, prevFiring)
+	svc.Status = models.EffectiveStatus(svc.ReportedStatus, svc.Firing)
+	m.applyImpact(svc)
+
+	err = m.db.Conn.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		UpdateAll: true,
+	}).Create(svc).Error
+	if err != nil {
+		log.Printf("Failed to store virtual service %s: %v", def.ID, err)
+		return
+	}
+	m.recordHistory(svc)
+
+	if existing == nil {
+		m.recordEvent(models.Event{
+			ServiceID: svc.ID,
+			Type:      models.EventRegistered,
+			Status:    svc.Status,
+			Message:   fmt.Sprintf("Virtual service '%s' created", svc.Name),
+		})
+		m.refreshVirtual(svc.ID)
+		return
+	}
+	if existing.Status != svc.Status {
+		m.recordStatusChange(svc, existing.Status, statusReason(svc))
+		return
+	}
+	m.refreshVirtual(svc.ID)
+}
+
+// virtualEnv exposes the state of the given services to expressions as
+// services["id"].status, services["id"].components["cpu"].value, etc.
+func (m *Manager) virtualEnv(ids []string) map[string]any {
+	env := make(map[string]any, len(ids))
+	if len(ids) == 0 {
+		return env
+	}
+
+	var services []models.Service
+	err := m.db.Conn.Omit("layout", "markdown_docs").Where("id IN ?", ids).Find(&services).Error
+	if err != nil {
+		log.Printf("Failed to load virtual service inputs: %v", err)
+		return env
+	}
+	for _, svc := range services {
+		components := make(map[string]any, len(svc.Components))
+		for compID, comp := range svc.Components {
+			components[compID] = map[string]any{
+				"value": comp.Value,
+				"type":  comp.Type,
+				"label": comp.Label,
+				"unit":  comp.Unit,
+			}
+		}
+		env[svc.ID] = map[string]any{
+			"id":              svc.ID,
+			"name":            svc.Name,
+			"group":           svc.Group,
+			"status":          svc.Status,
+			"reported_status": svc.ReportedStatus,
+			"message":         svc.Message,
+			"last_seen":       svc.LastSeen,
+			"components":      components,
+		}
+	}
+	return env
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+	"github.com/wbw1537/synapse/internal/validation"
+	"gorm.io/gorm"
+)
+
+// sourceWebhook marks services registered by an inbound webhook
+const sourceWebhook = "webhook"
+
+// signatureHeaders carry an HMAC-SHA256 of the body, hex encoded, keyed
+// with the hook secret (GitHub style "sha256=" prefix allowed)
+var signatureHeaders = []string{"X-Hub-Signature-256", "X-Gitea-Signature", "X-Gogs-Signature", "X-Synapse-Signature"}
+
+// ListWebhooks returns all webhooks. Secrets are never included.
+func (m *Manager) ListWebhooks() ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	if err := m.db.Conn.Order("id").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// GetWebhook returns a single webhook without its secret
+func (m *Manager) GetWebhook(id string) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := m.db.Conn.First(&hook, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	hook.Secret = ""
+	return &hook, nil
+}
+
+// SaveWebhook creates or replaces a webhook. A new webhook without a secret
+// gets a generated one, set on hook.Secret; an update without a secret keeps
+// the old one. Invalid definitions return a *validation.Error.
+func (m *Manager) SaveWebhook(hook *models.Webhook) error {
+	report := &validation.Report{}
+	validation.CheckWebhook(hook, report)
+	if report.HasErrors() {
+		return &validation.Error{Report: report}
+	}
+
+	var prev models.Webhook
+	result := m.db.Conn.Where("id = ?", hook.ID).Limit(1).Find(&prev)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	generated := false
+	if result.RowsAffected > 0 {
+		hook.CreatedAt = prev.CreatedAt
+		hook.LastReceived, hook.LastError = prev.LastReceived, prev.LastError
+		if hook.Secret == "" {
+			hook.Secret = prev.Secret
+		}
+	} else if hook.Secret == "" {
+		secret, err := randomHex(24)
+		if err != nil {
+			return fmt.Errorf("failed to generate secret: %w", err)
+		}
+		hook.Secret = secret
+		generated = true
+	}
+
+	if err := m.db.Conn.Save(hook).Error; err != nil {
+		return fmt.Errorf("db error: %w", err)
+	}
+	if !generated {
+		hook.Secret = ""
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook. Its services stay until they expire or are
+// deleted.
+func (m *Manager) DeleteWebhook(id string) error {
+	result := m.db.Conn.Delete(&models.Webhook{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("db error: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReceiveWebhook maps an inbound request onto a service and registers it like
+// a discovery payload. It returns the service ID. Unknown hooks return
+// gorm.ErrRecordNotFound, rejected requests a *validation.Error.
+func (m *Manager) ReceiveWebhook(id string, header http.Header, query url.Values, body []byte) (string, error) {
+	var hook models.Webhook
+	if err := m.db.Conn.First(&hook, "id = ?", id).Error; err != nil {
+		return "", err
+	}
+
+	serviceID, err := m.receiveWebhook(&hook, header, query, body)
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+	}
+	storeErr := m.db.Conn.Model(&hook).Select("last_received", "last_error").Updates(map[string]any{
+		"last_received": time.Now(),
+		"last_error":    lastError,
+	}).Error
+	if storeErr != nil {
+		log.Printf("Failed to store last request of webhook %s: %v", id, storeErr)
+	}
+	return serviceID, err
+}
+
+func (m *Manager) receiveWebhook(hook *models.Webhook, header http.Header, query url.Values, body []byte) (string, error) {
+	report := &validation.Report{}
+	if !webhookAuthorized(hook.Secret, header, query, body) {
+		report.Errorf("", "unauthorized", "missing or invalid webhook secret")
+		return "", m.reject("", report)
+	}
+
+	req := evaluator.Request{
+		Headers: make(map[string]string, len(header)),
+		Query:   make(map[string]string, len(query)),
+	}
+	if err := json.Unmarshal(body, &req.Body); err != nil {
+		req.Body = string(body)
+	}
+	for name := range header {
+		switch name {
+		case "Authorization", "X-Synapse-Secret":
+			continue
+		}
+		req.Headers[strings.ToLower(name)] = header.Get(name)
+	}
+	for name := range query {
+		if name != "secret" {
+			req.Query[name] = query.Get(name)
+		}
+	}
+
+	serviceID := extractString(hook.ServiceID, req, "service_id", report)
+	if serviceID == "" && !report.HasErrors() {
+		report.Errorf("service_id", "missing_field", "service_id evaluated to an empty string")
+	}
+	if report.HasErrors() {
+		return "", m.reject("", report)
+	}
+
+	svc := hook.Service(serviceID)
+	svc.Source, svc.SourceID = sourceWebhook, hook.ID
+	if name := extractString(hook.Name, req, "name", report); name != "" {
+		svc.Name = name
+	}
+	svc.Status = extractString(hook.Status, req, "status", report)
+	svc.Message = extractString(hook.Message, req, "message", report)
+	for compID, wc := range hook.Components {
+		if wc.Expr == "" {
+			continue
+		}
+		value, err := evaluator.Extract(wc.Expr, req)
+		if err != nil {
+			report.Errorf("components."+compID+".expr", "expr_error", "%v", err)
+			continue
+		}
+		comp := svc.Components[compID]
+		comp.Value = value
+		svc.Components[compID] = comp
+	}
+	if report.HasErrors() {
+		return serviceID, m.reject(serviceID, report)
+	}
+
+	// Like a scrape target, a webhook must not write into an axon's service,
+	// nor into one of another webhook. Services stored before the owning hook
+	// was recorded are adopted.
+	if existing, err := m.Get(serviceID); err == nil && (existing.Source != sourceWebhook || (existing.SourceID != "" && existing.SourceID != hook.ID)) {
+		report.Errorf("service_id", "id_taken", "service '%s' is registered by another source", serviceID)
+		return serviceID, m.reject(serviceID, report)
+	}
+
+	p := models.ServicePayload{Service: *svc}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return serviceID, err
+	}
+	return serviceID, m.register("", &p, payload)
+}
+
+// extractString evaluates an optional mapping expression that must yield a
+// string. Errors are added to the report.
+func extractString(expression string, req evaluator.Request, path string, report *validation.Report) string {
+	if expression == "" {
+		return ""
+	}
+	value, err := evaluator.Extract(expression, req)
+	if err != nil {
+		report.Errorf(path, "expr_error", "%v", err)
+		return ""
+	}
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		report.Errorf(path, "expr_error", "%s must evaluate to a string, got %T", path, value)
+		return ""
+	}
+}
+
+// webhookAuthorized checks the hook secret, sent as X-Synapse-Secret, a
+// bearer token, the "secret" query parameter or an HMAC signature of the body
+func webhookAuthorized(secret string, header http.Header, query url.Values, body []byte) bool {
+	equal := func(given string) bool {
+		return subtle.ConstantTimeCompare([]byte(given), []byte(secret)) == 1
+	}
+	if v := header.Get("X-Synapse-Secret"); v != "" {
+		return equal(v)
+	}
+	if v, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); ok {
+		return equal(v)
+	}
+	if v := query.Get("secret"); v != "" {
+		return equal(v)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	sum := hex.EncodeToString(mac.Sum(nil))
+	for _, name := range signatureHeaders {
+		if v := strings.TrimPrefix(header.Get(name), "sha256="); v != "" {
+			return hmac.Equal([]byte(strings.ToLower(v)), []byte(sum))
+		}
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"sort"
+
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// CheckOverride validates the policy override of a service. Cycles through
+// other services' dependencies are checked by the caller.
+func CheckOverride(o *models.ServiceOverride, r *Report) {
+	checkDependsOn(o.ServiceID, o.DependsOn, r)
+
+	compIDs := make([]string, 0, len(o.Monitors))
+	for compID := range o.Monitors {
+		compIDs = append(compIDs, compID)
+	}
+	sort.Strings(compIDs)
+	for _, compID := range compIDs {
+		checkMonitors(o.Monitors[compID], "monitors."+compID, r)
+	}
+}
@@ -0,0 +1,240 @@
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a single finding of a validation run. Path points at the offending
+// field in the source document (TOML or wire JSON).
+type Issue = models.ValidationIssue
+
+// Report collects the issues of a validation run
+type Report struct {
+	Issues []Issue `json:"issues"`
+}
+
+// Errorf adds an error-level issue
+func (r *Report) Errorf(path, code, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Path: path, Severity: SeverityError, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Warnf adds a warning-level issue
+func (r *Report) Warnf(path, code, format string, args ...any) {
+	r.Issues = append(r.Issues, Issue{Path: path, Severity: SeverityWarning, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// HasErrors reports whether any error-level issue was found
+func (r *Report) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings returns only the warning-level issues
+func (r *Report) Warnings() []Issue {
+	var warnings []Issue
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityWarning {
+			warnings = append(warnings, issue)
+		}
+	}
+	return warnings
+}
+
+// Style selects how layout paths are spelled in issues
+type Style int
+
+const (
+	// StyleTOML spells paths like axon.toml: layout.section[0].components[1]
+	StyleTOML Style = iota
+	// StyleWire spells paths like the JSON payload: layout.root[0].children[1]
+	StyleWire
+)
+
+func (s Style) sectionPath(i int) string {
+	if s == StyleTOML {
+		return fmt.Sprintf("layout.section[%d]", i)
+	}
+	return fmt.Sprintf("layout.root[%d]", i)
+}
+
+func (s Style) childPath(i, j int) string {
+	if s == StyleTOML {
+		return fmt.Sprintf("layout.section[%d].components[%d]", i, j)
+	}
+	return fmt.Sprintf("layout.root[%d].children[%d]", i, j)
+}
+
+// KnownTypes lists the component types the dashboard can render
+var KnownTypes = map[string]bool{
+	"stat":             true,
+	"status_indicator": true,
+	"gauge":            true,
+	"log_stream":       true,
+	"action_group":     true,
+	"link":             true,
+	"job_history":      true,
+}
+
+// KnownSeverities lists the monitor severities understood by Core
+var KnownSeverities = map[string]bool{
+	"info":     true,
+	"warning":  true,
+	"error":    true,
+	"critical": true,
+}
+
+// CheckService runs the structural checks shared by axon.toml and the wire
+// protocol: ghost/orphan layout references, per-type required fields and
+// monitor compilation.
+func CheckService(svc *models.Service, style Style, r *Report) {
+	if svc.ID == "" {
+		r.Errorf(idPath(style), "missing_field", "service id is required")
+	}
+
+	// 1. Layout integrity
+	used := make(map[string]bool)
+	for i, section := range svc.Layout.Root {
+		if len(section.Children) == 0 {
+			r.Warnf(style.sectionPath(i), "empty_section", "section '%s' has no components", section.Title)
+		}
+		for j, child := range section.Children {
+			used[child] = true
+			if _, ok := svc.Components[child]; !ok {
+				r.Errorf(style.childPath(i, j), "ghost_component", "layout references undefined component '%s'", child)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(svc.Components))
+	for id := range svc.Components {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	actions := make(map[string]string)
+	for _, id := range ids {
+		comp := svc.Components[id]
+		path := "components." + id
+
+		if len(svc.Layout.Root) > 0 && !used[id] {
+			r.Warnf(path, "orphan_component", "component '%s' is not used in the layout", id)
+		}
+
+		// 2. Per-type checks
+		checkComponent(comp, path, r)
+
+		for k, item := range comp.Items {
+			if prev, ok := actions[item.ActionID]; ok && item.ActionID != "" {
+				r.Errorf(fmt.Sprintf("%s.items[%d].%s", path, k, actionIDKey(style)), "duplicate_action", "action '%s' is already defined by component '%s'", item.ActionID, prev)
+				continue
+			}
+			actions[item.ActionID] = id
+		}
+
+		// 3. Monitors
+		checkMonitors(comp.Monitors, path+".monitors", r)
+	}
+}
+
+// checkMonitors validates a list of monitors found at path
+func checkMonitors(monitors []models.Monitor, path string, r *Report) {
+	for k, mon := range monitors {
+		monPath := fmt.Sprintf("%s[%d]", path, k)
+		if err := evaluator.Validate(mon.Condition); err != nil {
+			// expr appends a source excerpt on further lines
+			msg, _, _ := strings.Cut(err.Error(), "\n")
+			r.Errorf(monPath+".condition", "invalid_monitor", "%s", msg)
+		}
+		if !KnownSeverities[mon.Severity] {
+			r.Warnf(monPath+".severity", "unknown_severity", "unknown severity '%s' (use %s)", mon.Severity, strings.Join(severityNames(), ", "))
+		}
+	}
+}
+
+func checkComponent(comp models.Component, path string, r *Report) {
+	if comp.Type == "" {
+		r.Errorf(path+".type", "missing_field", "component type is required")
+		return
+	}
+	if !KnownTypes[comp.Type] {
+		r.Errorf(path+".type", "unknown_type", "unknown component type '%s'", comp.Type)
+		return
+	}
+
+	switch comp.Type {
+	case "gauge":
+		// An unset max is the default of 100, not 0
+		if max := comp.GaugeMax(); max <= comp.Min {
+			r.Errorf(path+".max", "invalid_range", "gauge requires a max greater than min (min=%v, max=%v)", comp.Min, max)
+		}
+	case "status_indicator":
+		if len(comp.Mapping) == 0 {
+			r.Errorf(path+".mapping", "missing_field", "status_indicator requires a mapping")
+		}
+		keys := make([]string, 0, len(comp.Mapping))
+		for key := range comp.Mapping {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if comp.Mapping[key].Text == "" {
+				r.Warnf(path+".mapping."+key+".text", "missing_field", "mapping '%s' has no text", key)
+			}
+		}
+	case "log_stream", "job_history":
+		if comp.MaxItems < 0 {
+			r.Errorf(path+".max_items", "invalid_range", "max_items must not be negative")
+		}
+	case "action_group":
+		if len(comp.Items) == 0 {
+			r.Errorf(path+".items", "missing_field", "action_group requires at least one item")
+		}
+		for k, item := range comp.Items {
+			if item.ActionID == "" {
+				r.Errorf(fmt.Sprintf("%s.items[%d]", path, k), "missing_field", "action item requires an id")
+			}
+		}
+	case "link":
+		if comp.URI == "" {
+			r.Errorf(path+".uri", "missing_field", "link requires a uri")
+		}
+	}
+}
+
+func idPath(style Style) string {
+	if style == StyleTOML {
+		return "meta.id"
+	}
+	return "id"
+}
+
+func actionIDKey(style Style) string {
+	if style == StyleTOML {
+		return "id"
+	}
+	return "action_id"
+}
+
+func severityNames() []string {
+	names := make([]string, 0, len(KnownSeverities))
+	for name := range KnownSeverities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
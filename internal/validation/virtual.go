@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+// CheckVirtual validates the definition of a virtual service
+func CheckVirtual(v *models.VirtualService, r *Report) {
+	if len(v.Components) == 0 {
+		r.Errorf("components", "missing_field", "a virtual service needs at least one component")
+	}
+
+	ids := make([]string, 0, len(v.Components))
+	for id := range v.Components {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		comp := v.Components[id]
+		path := "components." + id
+		if comp.Type == "action_group" {
+			r.Errorf(path+".type", "unsupported_component", "virtual services have no axon to run actions")
+			continue
+		}
+		if comp.Type == "link" {
+			continue
+		}
+
+		inputs, err := evaluator.Inputs(comp.Expr)
+		if err != nil {
+			r.Errorf(path+".expr", "invalid_expr", "%v", err)
+			continue
+		}
+		if slices.Contains(inputs, v.ID) {
+			r.Errorf(path+".expr", "invalid_expr", "a virtual service cannot read itself")
+		}
+	}
+
+	CheckService(v.Service(), StyleWire, r)
+}
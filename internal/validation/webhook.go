@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/wbw1537/synapse/internal/evaluator"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+var hookIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CheckWebhook validates the definition of an inbound webhook
+func CheckWebhook(w *models.Webhook, r *Report) {
+	if !hookIDPattern.MatchString(w.ID) {
+		r.Errorf("id", "invalid_id", "hook id '%s' may only contain letters, digits, '_' and '-'", w.ID)
+	}
+	if w.TTL < 0 {
+		r.Errorf("ttl", "invalid_range", "ttl must not be negative")
+	}
+
+	if err := evaluator.CheckExtract(w.ServiceID); err != nil {
+		r.Errorf("service_id", "invalid_expr", "%v", err)
+	}
+	optional := []struct{ path, expression string }{
+		{"name", w.Name},
+		{"status", w.Status},
+		{"message", w.Message},
+	}
+	for _, field := range optional {
+		if field.expression == "" {
+			continue
+		}
+		if err := evaluator.CheckExtract(field.expression); err != nil {
+			r.Errorf(field.path, "invalid_expr", "%v", err)
+		}
+	}
+
+	ids := make([]string, 0, len(w.Components))
+	for id := range w.Components {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		comp := w.Components[id]
+		path := "components." + id
+		if comp.Type == "action_group" {
+			r.Errorf(path+".type", "unsupported_component", "webhook services have no axon to run actions")
+			continue
+		}
+		if comp.Type == "link" && comp.Expr == "" {
+			continue
+		}
+		if err := evaluator.CheckExtract(comp.Expr); err != nil {
+			r.Errorf(path+".expr", "invalid_expr", "%v", err)
+		}
+	}
+
+	CheckService(w.Service(w.ID), StyleWire, r)
+}
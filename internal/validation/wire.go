@@ -0,0 +1,140 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/wbw1537/synapse/internal/cron"
+	"github.com/wbw1537/synapse/internal/models"
+)
+
+var instancePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// KnownStatuses lists the statuses an axon may report
+var KnownStatuses = map[string]bool{
+	"online":  true,
+	"warning": true,
+	"error":   true,
+	"offline": true,
+	// Set by the axon itself to announce planned downtime
+	"maintenance": true,
+}
+
+// CheckPayload validates a discovery payload received on the wire.
+// topicID is the ID taken from the MQTT topic, empty for HTTP.
+func CheckPayload(topicID string, p *models.ServicePayload, r *Report) {
+	if topicID != "" && p.ID != "" && topicID != p.ID {
+		r.Errorf("id", "id_mismatch", "payload id '%s' does not match topic id '%s'", p.ID, topicID)
+	}
+	if p.Status != "" && !KnownStatuses[p.Status] {
+		r.Errorf("status", "invalid_status", "unknown status '%s' (use online, warning, error, offline or maintenance)", p.Status)
+	}
+	if p.TTL < 0 {
+		r.Errorf("ttl", "invalid_range", "ttl must not be negative")
+	}
+	checkDependsOn(p.ID, p.DependsOn, r)
+
+	CheckInstance(p.Instance, r)
+	if a := p.Aggregation; a != nil {
+		switch a.Status {
+		case "", models.AggregateAll, models.AggregateAny, models.AggregateQuorum:
+		default:
+			r.Errorf("aggregation.status", "invalid_aggregation", "unknown status rule '%s' (use all, any or quorum)", a.Status)
+		}
+		for compID, rule := range a.Components {
+			path := "aggregation.components." + compID
+			switch rule {
+			case models.AggregateSum, models.AggregateAvg, models.AggregateMin, models.AggregateMax, models.AggregateLast:
+			default:
+				r.Errorf(path, "invalid_aggregation", "unknown rule '%s' (use sum, avg, min, max or last)", rule)
+			}
+			if _, ok := p.Components[compID]; !ok {
+				r.Warnf(path, "ghost_component", "aggregation references undefined component '%s'", compID)
+			}
+		}
+	}
+
+	if p.Schedule != nil {
+		CheckSchedule(p.Schedule, r)
+	}
+
+	CheckService(&p.Service, StyleWire, r)
+}
+
+// CheckSchedule validates the cron schedule of a job service
+func CheckSchedule(s *models.JobSchedule, r *Report) {
+	if _, err := cron.Parse(s.Cron); err != nil {
+		r.Errorf("schedule.cron", "invalid_schedule", "%v", err)
+	}
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			r.Errorf("schedule.timezone", "invalid_schedule", "unknown timezone '%s'", s.Timezone)
+		}
+	}
+	if s.Grace < 0 {
+		r.Errorf("schedule.grace", "invalid_range", "grace must not be negative")
+	}
+	if s.MaxDuration < 0 {
+		r.Errorf("schedule.max_duration", "invalid_range", "max_duration must not be negative")
+	}
+}
+
+// CheckInstance validates the replica name of a discovery or state payload
+func CheckInstance(instance string, r *Report) {
+	if instance != "" && !instancePattern.MatchString(instance) {
+		r.Errorf("instance", "invalid_instance", "instance '%s' may only contain letters, digits, '.', '_' and '-'", instance)
+	}
+}
+
+// Error is returned when a payload is rejected. It carries the full report
+// so it can be handed back to the axon.
+type Error struct {
+	ServiceID string
+	Report    *Report
+}
+
+func (e *Error) Error() string {
+	for _, issue := range e.Report.Issues {
+		if issue.Severity != SeverityError {
+			continue
+		}
+		if issue.Path == "" {
+			return issue.Message
+		}
+		return fmt.Sprintf("%s: %s", issue.Path, issue.Message)
+	}
+	return "payload rejected"
+}
+
+// Document is the machine-readable reply published to synapse/v1/error/{id}
+// and returned in the body of a rejected HTTP request
+type Document struct {
+	ServiceID string    `json:"service_id"`
+	Accepted  bool      `json:"accepted"`
+	Issues    []Issue   `json:"issues"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Document renders the error for the axon
+func (e *Error) Document() Document {
+	return Document{
+		ServiceID: e.ServiceID,
+		Accepted:  false,
+		Issues:    e.Report.Issues,
+		Timestamp: time.Now(),
+	}
+}
+
+// checkDependsOn rejects empty dependencies and a service depending on itself
+func checkDependsOn(id string, deps []string, r *Report) {
+	for i, dep := range deps {
+		path := fmt.Sprintf("depends_on[%d]", i)
+		switch dep {
+		case "":
+			r.Errorf(path, "invalid_dependency", "dependency id must not be empty")
+		case id:
+			r.Errorf(path, "invalid_dependency", "a service cannot depend on itself")
+		}
+	}
+}
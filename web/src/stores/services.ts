@@ -43,7 +43,7 @@ export interface Service {
   tags: string[]
   icon: string
   url: string
-  status: 'online' | 'warning' | 'error' | 'offline'
+  status: 'online' | 'warning' | 'error' | 'offline' | 'maintenance' | 'impacted'
   message: string
   ttl: number
   description: string
@@ -54,6 +54,23 @@ export interface Service {
   layout?: LayoutSchema
   components?: Record<string, Component>
   
+  depends_on?: string[]
+  impacted_by?: string[]
+  virtual?: boolean
+  source?: string
+  source_id?: string
+
+  // Multi-instance services (detail view only)
+  aggregation?: { status?: string; components?: Record<string, string> }
+  instances?: { instance: string; status: string; ttl: number; last_seen: string }[]
+
+  // Job services
+  schedule?: { cron: string; timezone?: string; grace?: number; max_duration?: number }
+  job?: { state: 'ok' | 'running' | 'failed' | 'missed' | 'overlong'; message?: string; last_run?: string; next_run?: string }
+
+  // Computed by Core for the list
+  uptime?: { window: string; percent: number }
+
   last_seen: string
 }
 
@@ -78,6 +95,20 @@ export const useServiceStore = defineStore('services', () => {
     }
   }
 
+  const fetchService = async (id: string) => {
+    try {
+      const response = await fetch(`/api/v1/services/${id}`)
+      if (!response.ok) return
+      const data: Service = await response.json()
+      if (!data.uptime && services.value[id]?.uptime) {
+        data.uptime = services.value[id].uptime
+      }
+      services.value[id] = data
+    } catch (err) {
+      console.error('Failed to fetch service:', err)
+    }
+  }
+
   const selectService = (id: string | null) => {
     selectedServiceId.value = id
   }
@@ -110,11 +141,59 @@ export const useServiceStore = defineStore('services', () => {
     client.on('connect', () => {
       connected.value = true
       client.subscribe('synapse/v1/discovery/#')
+      client.subscribe('synapse/v1/state/#')
+      client.subscribe('synapse/v1/status/#')
     })
 
-    client.on('message', (_topic, payload) => {
+    client.on('message', (topic, payload) => {
       try {
-        const newData = JSON.parse(payload.toString()) as Service
+        // Effective status changes published by Core (e.g. missed heartbeats)
+        if (topic.startsWith('synapse/v1/status/')) {
+          const update = JSON.parse(payload.toString())
+          const existing = services.value[update.service_id]
+          if (existing) {
+            existing.status = update.status
+          }
+          return
+        }
+
+        // State updates only carry the changed values, the service ID is in the topic
+        if (topic.startsWith('synapse/v1/state/')) {
+          const id = topic.slice('synapse/v1/state/'.length)
+          const update = JSON.parse(payload.toString()) as { instance?: string; message?: string; components?: Record<string, any> }
+          const existing = services.value[id]
+          if (!existing) return
+
+          if (update.instance) {
+            fetchService(id)
+            return
+          }
+          if (update.message) {
+            existing.message = update.message
+          }
+          Object.entries(update.components || {}).forEach(([compId, value]) => {
+            const comp = existing.components?.[compId]
+            if (!comp) return
+
+            // Log lines are appended like on discovery, everything else replaces
+            if (comp.type === 'log_stream' && typeof value === 'string') {
+              const logs = Array.isArray(comp.value) ? [...comp.value] : comp.value ? [comp.value] : []
+              logs.push(value)
+              comp.value = logs.slice(-(comp.max_items || 10))
+            } else {
+              comp.value = value
+            }
+          })
+          return
+        }
+
+        const newData = JSON.parse(payload.toString()) as Service & { instance?: string }
+
+        // A replica only carries its own values, Core holds the aggregate
+        if (newData.id && newData.instance) {
+          fetchService(newData.id)
+          return
+        }
         
         if (newData.id) {
           const existing = services.value[newData.id]
@@ -162,6 +241,11 @@ export const useServiceStore = defineStore('services', () => {
             // (Though we modified newComp in place, which is inside newData)
           }
           
+          // Discovery payloads come straight from the axon, keep Core's computed fields
+          if (existing?.uptime && !newData.uptime) {
+            newData.uptime = existing.uptime
+          }
+
           services.value[newData.id] = newData
         }
       } catch (err) {